@@ -0,0 +1,304 @@
+package godbsql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/goenvars"
+	"github.com/Nemutagk/golog"
+)
+
+const (
+	AggregateCount  = "COUNT"
+	AggregateSum    = "SUM"
+	AggregateMax    = "MAX"
+	AggregateExists = "EXISTS"
+)
+
+// Agregado sobre una relación que se escribe en un campo del padre sin cargar los hijos
+type RelationAggregate struct {
+	Relation string
+	Function string
+	Column   string
+	Field    string
+	Filters  *models.GroupFilter
+}
+
+// Loader capaz de calcular agregados agrupados por la llave del padre
+type RelationAggregator interface {
+	Aggregate(ctx context.Context, parentModels []any, aggregate RelationAggregate, loadOpts *RelationLoadOptions) error
+}
+
+type relationAggregatesKey struct{}
+
+// WithRelationAggregates pide agregados (withCount, withSum, withMax, withExists) junto a las relaciones de Get.
+// Relation acepta rutas con puntos ("posts.comments") para calcularlos sobre una relación cargada.
+// Igual que las restricciones, solo aplican a la llamada a Get o LoadRelations que recibe el contexto
+func WithRelationAggregates(ctx context.Context, aggregates ...RelationAggregate) context.Context {
+	return context.WithValue(ctx, relationAggregatesKey{}, aggregates)
+}
+
+func relationAggregatesFrom(ctx context.Context) []RelationAggregate {
+	aggregates, _ := ctx.Value(relationAggregatesKey{}).([]RelationAggregate)
+	return aggregates
+}
+
+func (l *OnetoManyLoader[P, C]) Aggregate(ctx context.Context, parentModels []any, aggregate RelationAggregate, loadOpts *RelationLoadOptions) error {
+	if err := l.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	childTable := l.Repository.GetTableName()
+	if err := validateTableName(childTable); err != nil {
+		return err
+	}
+
+	expression, err := aggregateExpression(aggregate, "")
+	if err != nil {
+		return err
+	}
+
+	parentIds, err := collectFieldValues(parentModels, l.ParentField)
+	if err != nil {
+		return err
+	}
+
+	filters := models.GroupFilter{Filters: []any{}}
+	if aggregate.Filters != nil {
+		filters = *aggregate.Filters
+	}
+
+	if softDelete, ok := l.Repository.(softDeleteFilterer); ok {
+		filters = softDelete.softDeleteFilters(filters)
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT ")
+	queryBuilder.WriteString(l.ChildFkField)
+	queryBuilder.WriteString(", ")
+	queryBuilder.WriteString(expression)
+	queryBuilder.WriteString(" FROM ")
+	queryBuilder.WriteString(childTable)
+	queryBuilder.WriteString(" WHERE ")
+
+	inQuery, args, counter := inClause(l.ChildFkField, ComparatorIn, parentIds, 1)
+	queryBuilder.WriteString(inQuery)
+
+	extraFilters, extraVals, _, err := prepareFilters(filters, counter, repositoryFilterScope(l.Repository, childTable, 0))
+	if err != nil {
+		return err
+	}
+	if extraFilters != "" {
+		queryBuilder.WriteString(" AND (")
+		queryBuilder.WriteString(extraFilters)
+		queryBuilder.WriteString(")")
+		args = append(args, extraVals...)
+	}
+
+	queryBuilder.WriteString(" GROUP BY ")
+	queryBuilder.WriteString(l.ChildFkField)
+
+	results, err := queryAggregate(ctx, l.Repository.GetConnection(), loadOpts, queryBuilder.String(), args)
+	if err != nil {
+		return err
+	}
+
+	return writeAggregate(parentModels, l.ParentField, aggregate, results)
+}
+
+func (m *ManyToManyLoader[P, C]) Aggregate(ctx context.Context, parentModels []any, aggregate RelationAggregate, loadOpts *RelationLoadOptions) error {
+	if err := m.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	childTable := m.Repository.GetTableName()
+	if err := validateTableName(childTable); err != nil {
+		return err
+	}
+
+	expression, err := aggregateExpression(aggregate, "godbsql_child.")
+	if err != nil {
+		return err
+	}
+
+	parentIds, err := collectFieldValues(parentModels, m.ParentKey)
+	if err != nil {
+		return err
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT godbsql_pivot.")
+	queryBuilder.WriteString(m.PivoteParentKey)
+	queryBuilder.WriteString(", ")
+	queryBuilder.WriteString(expression)
+	queryBuilder.WriteString(" FROM (SELECT * FROM ")
+	queryBuilder.WriteString(m.PivoteTable)
+	queryBuilder.WriteString(" WHERE ")
+
+	inQuery, args, counter := inClause(m.PivoteParentKey, ComparatorIn, parentIds, 1)
+	queryBuilder.WriteString(inQuery)
+
+	if m.PivoteFilters != nil {
+		pivotFilters, pivotVals, newCounter, err := prepareFilters(*m.PivoteFilters, counter, nil)
+		if err != nil {
+			return err
+		}
+		counter = newCounter
+		if pivotFilters != "" {
+			queryBuilder.WriteString(" AND (")
+			queryBuilder.WriteString(pivotFilters)
+			queryBuilder.WriteString(")")
+			args = append(args, pivotVals...)
+		}
+	}
+
+	queryBuilder.WriteString(") AS godbsql_pivot JOIN (SELECT * FROM ")
+	queryBuilder.WriteString(childTable)
+
+	childFilters := models.GroupFilter{Filters: []any{}}
+	if aggregate.Filters != nil {
+		childFilters = *aggregate.Filters
+	}
+
+	if softDelete, ok := m.Repository.(softDeleteFilterer); ok {
+		childFilters = softDelete.softDeleteFilters(childFilters)
+	}
+
+	childWhere, childVals, _, err := prepareFilters(childFilters, counter, repositoryFilterScope(m.Repository, childTable, 0))
+	if err != nil {
+		return err
+	}
+	if childWhere != "" {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(childWhere)
+		args = append(args, childVals...)
+	}
+
+	queryBuilder.WriteString(") AS godbsql_child ON godbsql_child.")
+	queryBuilder.WriteString(m.ChildKey)
+	queryBuilder.WriteString(" = godbsql_pivot.")
+	queryBuilder.WriteString(m.PivoteChildKey)
+	queryBuilder.WriteString(" GROUP BY godbsql_pivot.")
+	queryBuilder.WriteString(m.PivoteParentKey)
+
+	results, err := queryAggregate(ctx, m.Connection, loadOpts, queryBuilder.String(), args)
+	if err != nil {
+		return err
+	}
+
+	return writeAggregate(parentModels, m.ParentKey, aggregate, results)
+}
+
+func aggregateExpression(aggregate RelationAggregate, qualifier string) (string, error) {
+	switch strings.ToUpper(aggregate.Function) {
+	case AggregateCount, AggregateExists:
+		return "COUNT(*)", nil
+	case AggregateSum, AggregateMax:
+		if aggregate.Column == "" {
+			return "", fmt.Errorf("aggregate %s requires a column", aggregate.Function)
+		}
+
+		if err := validateIdentifier(aggregate.Column); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s(%s%s)", strings.ToUpper(aggregate.Function), qualifier, aggregate.Column), nil
+	}
+
+	return "", fmt.Errorf("invalid aggregate function: %s", aggregate.Function)
+}
+
+func collectFieldValues(parentModels []any, fieldName string) ([]any, error) {
+	values := make([]any, 0, len(parentModels))
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		field := val.FieldByName(fieldName)
+		if !field.IsValid() {
+			return nil, fmt.Errorf("invalid parent field: %s", fieldName)
+		}
+
+		values = append(values, field.Interface())
+	}
+
+	return values, nil
+}
+
+// Ejecuta la consulta agrupada y devuelve el valor del agregado por llave del padre
+func queryAggregate(ctx context.Context, conn any, loadOpts *RelationLoadOptions, query string, args []any) (map[any]any, error) {
+	if goenvars.GetEnvBool("SQL_DEBUG", false) {
+		golog.Log(ctx, "SQL Query:", query)
+		golog.Log(ctx, "SQL Values:", args)
+	}
+
+	exec, err := resolveExecutor(conn, loadTransaction(loadOpts))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregate: %w", err)
+	}
+	defer rows.Close()
+
+	results := map[any]any{}
+	for rows.Next() {
+		var parentId, value any
+		if err := rows.Scan(&parentId, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate row: %w", err)
+		}
+
+		results[normalizeKey(parentId)] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return results, nil
+}
+
+func writeAggregate(parentModels []any, parentField string, aggregate RelationAggregate, results map[any]any) error {
+	function := strings.ToUpper(aggregate.Function)
+
+	for _, parent := range parentModels {
+		val := reflect.ValueOf(parent)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		field := val.FieldByName(aggregate.Field)
+		if !field.IsValid() {
+			return fmt.Errorf("invalid aggregate field: %s", aggregate.Field)
+		}
+
+		if !field.CanSet() {
+			return fmt.Errorf("cannot set aggregate field: %s", aggregate.Field)
+		}
+
+		value, exists := results[normalizeKey(val.FieldByName(parentField).Interface())]
+
+		switch function {
+		case AggregateCount:
+			if !exists {
+				value = int64(0)
+			}
+		case AggregateExists:
+			count, _ := value.(int64)
+			value = exists && count > 0
+		}
+
+		if err := setFieldValue(field, value); err != nil {
+			return fmt.Errorf("invalid aggregate value for field %s: %w", aggregate.Field, err)
+		}
+	}
+
+	return nil
+}
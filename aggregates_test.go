@@ -0,0 +1,62 @@
+package godbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aggregateTestModel struct {
+	Id string
+}
+
+func (m *aggregateTestModel) ScanFields() []any {
+	return []any{&m.Id}
+}
+
+func TestRelationCallAggregates(t *testing.T) {
+	ctx := WithRelationAggregates(context.Background(),
+		RelationAggregate{Relation: "comments", Function: AggregateCount, Field: "CommentsCount"},
+		RelationAggregate{Relation: "roles.permissions", Function: AggregateCount, Field: "PermissionsCount"},
+	)
+
+	stripped, call := takeRelationCall(ctx)
+	assert.True(t, relationCallFrom(stripped).isEmpty())
+
+	assert.Equal(t, []RelationAggregate{
+		{Relation: "comments", Function: AggregateCount, Field: "CommentsCount"},
+	}, call.levelAggregates())
+
+	assert.Equal(t, []RelationAggregate{
+		{Relation: "permissions", Function: AggregateCount, Field: "PermissionsCount"},
+	}, call.scope("roles").aggregates)
+	assert.True(t, call.scope("comments").isEmpty())
+}
+
+func TestValidateAggregatePaths(t *testing.T) {
+	call := relationCall{aggregates: []RelationAggregate{
+		{Relation: "comments", Function: AggregateCount, Field: "CommentsCount"},
+		{Relation: "roles.permissions", Function: AggregateCount, Field: "PermissionsCount"},
+	}}
+
+	nodes, err := buildRelationTree([]string{"roles"}, DefaultMaxRelationDepth)
+	require.NoError(t, err)
+	assert.NoError(t, call.validateAggregatePaths(nodes))
+
+	nodes, err = buildRelationTree([]string{"posts"}, DefaultMaxRelationDepth)
+	require.NoError(t, err)
+	assert.Error(t, call.validateAggregatePaths(nodes))
+}
+
+func TestLoadRelationsRejectsNestedAggregateWithoutRelation(t *testing.T) {
+	conn := &Connection[*aggregateTestModel]{Table: "users"}
+	ctx := WithRelationAggregates(context.Background(),
+		RelationAggregate{Relation: "roles.permissions", Function: AggregateCount, Field: "PermissionsCount"},
+	)
+
+	err := conn.LoadRelations(ctx, []*aggregateTestModel{{Id: "1"}}, nil, nil)
+
+	assert.ErrorContains(t, err, "requires loading relation roles")
+}
@@ -0,0 +1,189 @@
+package godbsql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+)
+
+// Caché de modelos cargados por los loaders durante una misma petición, indexados por repositorio + llave.
+// Es seguro usarla desde la carga en paralelo
+type RelationCache struct {
+	mu      sync.Mutex
+	entries map[relationCacheKey]relationCacheEntry
+	hits    int
+	misses  int
+}
+
+// Aciertos y fallos de la caché, útil para depurar cuántas consultas se evitaron
+type RelationCacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+}
+
+type relationCacheKey struct {
+	repository any
+	column     string
+	childs     string
+	key        any
+}
+
+// found en false recuerda que la llave no existe y evita volver a consultarla
+type relationCacheEntry struct {
+	model any
+	found bool
+}
+
+type relationCacheCtxKey struct{}
+
+func NewRelationCache() *RelationCache {
+	return &RelationCache{entries: map[relationCacheKey]relationCacheEntry{}}
+}
+
+// WithRelationCache activa la caché para los loaders que se ejecuten con el contexto devuelto
+func WithRelationCache(ctx context.Context, cache *RelationCache) context.Context {
+	return context.WithValue(ctx, relationCacheCtxKey{}, cache)
+}
+
+func relationCacheFrom(ctx context.Context) *RelationCache {
+	cache, _ := ctx.Value(relationCacheCtxKey{}).(*RelationCache)
+	return cache
+}
+
+func (c *RelationCache) Stats() RelationCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return RelationCacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+func (c *RelationCache) lookup(key relationCacheKey) (relationCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+
+	return entry, ok
+}
+
+func (c *RelationCache) store(key relationCacheKey, entry relationCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry
+}
+
+// La caché solo aplica si el resultado depende únicamente de la llave: fuera de una transacción (que ve filas
+// sin confirmar), sin restricciones en la relación ni en sus relaciones anidadas, y con un repositorio que
+// pueda usarse como llave de mapa
+func cacheForLoad(ctx context.Context, repo any, loadOpts *RelationLoadOptions) *RelationCache {
+	cache := relationCacheFrom(ctx)
+	if cache == nil {
+		return nil
+	}
+
+	if loadOpts != nil && (loadOpts.Constraint != nil || loadOpts.Transaction != nil) {
+		return nil
+	}
+
+	// Restricciones de las relaciones anidadas, el Get de los hijos las recibe en el contexto
+	if !relationCallFrom(ctx).isEmpty() {
+		return nil
+	}
+
+	if repo == nil || !reflect.TypeOf(repo).Comparable() {
+		return nil
+	}
+
+	return cache
+}
+
+// Carga los modelos de repo cuya columna está en ids, uno por llave (el primero encontrado gana).
+// Con una caché en el contexto solo se consultan las llaves que aún no se han cargado
+func loadByKey[C Model](ctx context.Context, repo repository.DriverConnection[C], column string, ids []any, childs *[]string, loadOpts *RelationLoadOptions) (map[any]C, error) {
+	ids = uniqueKeys(ids)
+	result := make(map[any]C, len(ids))
+	cache := cacheForLoad(ctx, repo, loadOpts)
+
+	childsKey := ""
+	if childs != nil {
+		childsKey = strings.Join(*childs, ",")
+	}
+
+	missing := ids
+	if cache != nil {
+		missing = []any{}
+		for _, id := range ids {
+			entry, ok := cache.lookup(relationCacheKey{repository: repo, column: column, childs: childsKey, key: normalizeKey(id)})
+			if !ok {
+				missing = append(missing, id)
+				continue
+			}
+
+			if entry.found {
+				result[normalizeKey(id)] = entry.model.(C)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	in := ComparatorIn
+	filters := models.GroupFilter{
+		Filters: []any{
+			models.FilterMultipleValue{
+				Key:        column,
+				Values:     missing,
+				Comparator: &in,
+			},
+		},
+	}
+
+	opts := relationChildOptions(childs, loadOpts, column)
+
+	loaded, err := repo.Get(ctx, relationChildFilters(filters, loadOpts), &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	keyField := prepareForeignKey(column)
+	for _, model := range loaded {
+		modelVal := reflect.ValueOf(model)
+		for modelVal.Kind() == reflect.Ptr {
+			modelVal = modelVal.Elem()
+		}
+
+		keyValue := modelVal.FieldByName(keyField)
+		if !keyValue.IsValid() {
+			return nil, fmt.Errorf("invalid key field: %s, %s", keyField, column)
+		}
+
+		key := normalizeKey(keyValue.Interface())
+		if _, exists := result[key]; !exists {
+			result[key] = model
+		}
+	}
+
+	if cache != nil {
+		for _, id := range missing {
+			key := normalizeKey(id)
+			model, found := result[key]
+			cache.store(relationCacheKey{repository: repo, column: column, childs: childsKey, key: key}, relationCacheEntry{model: model, found: found})
+		}
+	}
+
+	return result, nil
+}
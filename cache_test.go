@@ -0,0 +1,42 @@
+package godbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheForLoad(t *testing.T) {
+	cache := NewRelationCache()
+	cached := WithRelationCache(context.Background(), cache)
+	repo := &Connection[*aggregateTestModel]{Table: "users"}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		repo     any
+		loadOpts *RelationLoadOptions
+		expected *RelationCache
+	}{
+		{name: "sin caché", ctx: context.Background(), repo: repo, expected: nil},
+		{name: "sin opciones", ctx: cached, repo: repo, expected: cache},
+		{name: "opciones vacías", ctx: cached, repo: repo, loadOpts: &RelationLoadOptions{}, expected: cache},
+		{name: "con transacción", ctx: cached, repo: repo, loadOpts: &RelationLoadOptions{Transaction: &models.Transaction{}}, expected: nil},
+		{name: "con restricción", ctx: cached, repo: repo, loadOpts: &RelationLoadOptions{Constraint: &RelationConstraint{Limit: 1}}, expected: nil},
+		{
+			name:     "con restricciones anidadas",
+			ctx:      withRelationCall(cached, relationCall{constraints: map[string]RelationConstraint{"roles": {Limit: 1}}}),
+			repo:     repo,
+			expected: nil,
+		},
+		{name: "repositorio no comparable", ctx: cached, repo: map[string]any{}, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Same(t, tt.expected, cacheForLoad(tt.ctx, tt.repo, tt.loadOpts))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package godbsql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Cantidad de valores que liga cada comparador de models.Filter; cualquier otro comparador se rechaza
+var comparatorArity = map[string]int{
+	ComparatorEqual:              1,
+	ComparatorNotEqual:           1,
+	"<>":                         1,
+	ComparatorGreaterThan:        1,
+	ComparatorLessThan:           1,
+	ComparatorGreaterThanOrEqual: 1,
+	ComparatorLessThanOrEqual:    1,
+	ComparatorLike:               1,
+	ComparatorNotLike:            1,
+	ComparatorILike:              1,
+	ComparatorNotILike:           1,
+	ComparatorRegex:              1,
+	ComparatorRegexI:             1,
+	ComparatorNotRegex:           1,
+	ComparatorNotRegexI:          1,
+	ComparatorIsDistinctFrom:     1,
+	ComparatorIsNotDistinctFrom:  1,
+	ComparatorAny:                1,
+	ComparatorAll:                1,
+	ComparatorArrayContains:      1,
+	ComparatorArrayContainedBy:   1,
+	ComparatorArrayOverlaps:      1,
+	ComparatorBetween:            2,
+	ComparatorNotBetween:         2,
+	ComparatorIsNull:             0,
+	ComparatorIsNotNull:          0,
+}
+
+// Mayúsculas y espacios simples, así "not  like" y "NOT LIKE" son el mismo comparador
+func normalizeComparator(comparator string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(comparator), " "))
+}
+
+// Compila "key comparador valor" para un models.Filter con el número de parámetros de su comparador
+func prepareComparison(key, comparator string, value any, counter int) (string, []any, int, error) {
+	arity, ok := comparatorArity[comparator]
+	if !ok {
+		return "", nil, counter, fmt.Errorf("unsupported comparator: %q", comparator)
+	}
+
+	switch arity {
+	case 0:
+		return fmt.Sprintf("%s %s", key, comparator), nil, counter, nil
+	case 2:
+		values, err := comparatorValues(comparator, value, arity)
+		if err != nil {
+			return "", nil, counter, err
+		}
+
+		low, lowVals, counter, err := filterOperand(values[0], counter)
+		if err != nil {
+			return "", nil, counter, err
+		}
+
+		high, highVals, counter, err := filterOperand(values[1], counter)
+		if err != nil {
+			return "", nil, counter, err
+		}
+
+		return fmt.Sprintf("%s %s %s AND %s", key, comparator, low, high), append(lowVals, highVals...), counter, nil
+	}
+
+	arrayValue, isSlice := arrayParam(value)
+	if isSlice && (isArrayComparator(comparator) || comparator == ComparatorAny || comparator == ComparatorAll) {
+		value = arrayValue
+	}
+
+	operand, vals, counter, err := filterOperand(value, counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	if comparator == ComparatorAny || comparator == ComparatorAll {
+		if isSlice {
+			// La columna se compara contra los elementos del slice: status = ANY($1)
+			return fmt.Sprintf("%s = %s(%s)", key, comparator, operand), vals, counter, nil
+		}
+
+		// El valor se busca entre los elementos de la columna arreglo: $1 = ANY(tags)
+		return fmt.Sprintf("%s = %s(%s)", operand, comparator, key), vals, counter, nil
+	}
+
+	return fmt.Sprintf("%s %s %s", key, comparator, operand), vals, counter, nil
+}
+
+// Lado derecho de una comparación: una columna validada, una expresión RawSQL o un parámetro
+func filterOperand(value any, counter int) (string, []any, int, error) {
+	switch v := value.(type) {
+	case Column:
+		if err := validateIdentifier(string(v)); err != nil {
+			return "", nil, counter, err
+		}
+		return string(v), nil, counter, nil
+	case RawSQL:
+		return v.String(), nil, counter, nil
+	}
+
+	return fmt.Sprintf("$%d", counter), []any{value}, counter + 1, nil
+}
+
+// Los comparadores de varios valores (BETWEEN) reciben un slice o arreglo con exactamente esa cantidad
+func comparatorValues(comparator string, value any, arity int) ([]any, error) {
+	val := reflect.ValueOf(value)
+	if !val.IsValid() || (val.Kind() != reflect.Slice && val.Kind() != reflect.Array) || val.Len() != arity {
+		return nil, fmt.Errorf("comparator %s requires %d values", comparator, arity)
+	}
+
+	values := make([]any, 0, arity)
+	for i := 0; i < arity; i++ {
+		values = append(values, val.Index(i).Interface())
+	}
+
+	return values, nil
+}
+
+func isArrayComparator(comparator string) bool {
+	return comparator == ComparatorArrayContains || comparator == ComparatorArrayContainedBy || comparator == ComparatorArrayOverlaps
+}
+
+// Los slices de Go ([]string, []int64, ...) se envían como arreglo de Postgres con pq.Array.
+// []byte y los tipos con driver.Valuer se dejan igual
+func arrayParam(value any) (any, bool) {
+	if _, ok := value.(driver.Valuer); ok {
+		return value, false
+	}
+
+	val := reflect.ValueOf(value)
+	if !val.IsValid() || (val.Kind() != reflect.Slice && val.Kind() != reflect.Array) || val.Type().Elem().Kind() == reflect.Uint8 {
+		return value, false
+	}
+
+	return pq.Array(value), true
+}
@@ -0,0 +1,227 @@
+package godbsql
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareComparison(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		comparator    string
+		value         any
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "igual",
+			key:           "status",
+			comparator:    ComparatorEqual,
+			value:         "active",
+			counter:       1,
+			expectedQuery: "status = $1",
+			expectedArgs:  []any{"active"},
+			expectedNext:  2,
+		},
+		{
+			name:          "ILIKE",
+			key:           "name",
+			comparator:    ComparatorILike,
+			value:         "%ana%",
+			counter:       4,
+			expectedQuery: "name ILIKE $4",
+			expectedArgs:  []any{"%ana%"},
+			expectedNext:  5,
+		},
+		{
+			name:          "BETWEEN",
+			key:           "age",
+			comparator:    ComparatorBetween,
+			value:         []int{18, 30},
+			counter:       2,
+			expectedQuery: "age BETWEEN $2 AND $3",
+			expectedArgs:  []any{18, 30},
+			expectedNext:  4,
+		},
+		{
+			name:          "NOT BETWEEN contra columnas",
+			key:           "created_at",
+			comparator:    ComparatorNotBetween,
+			value:         []any{Column("starts_at"), Column("ends_at")},
+			counter:       1,
+			expectedQuery: "created_at NOT BETWEEN starts_at AND ends_at",
+			expectedArgs:  []any{},
+			expectedNext:  1,
+		},
+		{
+			name:          "BETWEEN con columna y parámetro",
+			key:           "price",
+			comparator:    ComparatorBetween,
+			value:         [2]any{Column("min_price"), 100},
+			counter:       3,
+			expectedQuery: "price BETWEEN min_price AND $3",
+			expectedArgs:  []any{100},
+			expectedNext:  4,
+		},
+		{
+			name:          "ANY con slice",
+			key:           "status",
+			comparator:    ComparatorAny,
+			value:         []string{"active", "pending"},
+			counter:       1,
+			expectedQuery: "status = ANY($1)",
+			expectedArgs:  []any{pq.Array([]string{"active", "pending"})},
+			expectedNext:  2,
+		},
+		{
+			name:          "ANY con valor sobre columna arreglo",
+			key:           "tags",
+			comparator:    ComparatorAny,
+			value:         "go",
+			counter:       2,
+			expectedQuery: "$2 = ANY(tags)",
+			expectedArgs:  []any{"go"},
+			expectedNext:  3,
+		},
+		{
+			name:          "ALL con slice",
+			key:           "score",
+			comparator:    ComparatorAll,
+			value:         []int64{1, 2},
+			counter:       1,
+			expectedQuery: "score = ALL($1)",
+			expectedArgs:  []any{pq.Array([]int64{1, 2})},
+			expectedNext:  2,
+		},
+		{
+			name:          "IS NULL",
+			key:           "deleted_at",
+			comparator:    ComparatorIsNull,
+			value:         nil,
+			counter:       3,
+			expectedQuery: "deleted_at IS NULL",
+			expectedNext:  3,
+		},
+		{
+			name:          "IS DISTINCT FROM",
+			key:           "manager_id",
+			comparator:    ComparatorIsDistinctFrom,
+			value:         nil,
+			counter:       1,
+			expectedQuery: "manager_id IS DISTINCT FROM $1",
+			expectedArgs:  []any{nil},
+			expectedNext:  2,
+		},
+		{
+			name:          "contra columna",
+			key:           "updated_at",
+			comparator:    ComparatorGreaterThan,
+			value:         Column("created_at"),
+			counter:       1,
+			expectedQuery: "updated_at > created_at",
+			expectedNext:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareComparison(tt.key, tt.comparator, tt.value, tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			if len(tt.expectedArgs) == 0 {
+				assert.Empty(t, args)
+			} else {
+				assert.Equal(t, tt.expectedArgs, args)
+			}
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareArrayComparison(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		comparator    string
+		value         any
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "arreglo contiene",
+			key:           "tags",
+			comparator:    ComparatorArrayContains,
+			value:         []string{"go", "sql"},
+			counter:       1,
+			expectedQuery: "tags @> $1",
+			expectedArgs:  []any{pq.Array([]string{"go", "sql"})},
+			expectedNext:  2,
+		},
+		{
+			name:          "arreglos se traslapan",
+			key:           "tags",
+			comparator:    ComparatorArrayOverlaps,
+			value:         []string{"go"},
+			counter:       5,
+			expectedQuery: "tags && $5",
+			expectedArgs:  []any{pq.Array([]string{"go"})},
+			expectedNext:  6,
+		},
+		{
+			name:          "arreglo contenido en []byte no se convierte",
+			key:           "payload",
+			comparator:    ComparatorArrayContainedBy,
+			value:         []byte("{1,2}"),
+			counter:       1,
+			expectedQuery: "payload <@ $1",
+			expectedArgs:  []any{[]byte("{1,2}")},
+			expectedNext:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareComparison(tt.key, tt.comparator, tt.value, tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareComparisonErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator string
+		value      any
+	}{
+		{name: "comparador desconocido", comparator: "= 1 OR 1 =", value: 1},
+		{name: "comparador vacío", comparator: "", value: 1},
+		{name: "IN en models.Filter", comparator: ComparatorIn, value: []int{1}},
+		{name: "BETWEEN con un valor", comparator: ComparatorBetween, value: []int{1}},
+		{name: "BETWEEN sin slice", comparator: ComparatorBetween, value: 1},
+		{name: "columna inválida", comparator: ComparatorEqual, value: Column("a; DROP TABLE users")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := prepareComparison("id", tt.comparator, tt.value, 1)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNormalizeComparator(t *testing.T) {
+	assert.Equal(t, ComparatorNotLike, normalizeComparator("not   like"))
+	assert.Equal(t, ComparatorIsNotDistinctFrom, normalizeComparator(" is not distinct from "))
+	assert.Equal(t, ComparatorRegexI, normalizeComparator("~*"))
+}
@@ -0,0 +1,95 @@
+package godbsql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+)
+
+// Restricciones aplicadas al Get de los hijos de una relación
+type RelationConstraint struct {
+	Filters     *models.GroupFilter
+	Columns     *[]string
+	OrderColumn string
+	OrderDir    string
+
+	// No se admite: la consulta de hijos cubre a todos los padres a la vez, así que un límite global dejaría
+	// a algunos padres sin hijos. Los loaders devuelven error si se define; usa PerParentLimit
+	Limit int
+
+	// Máximo de hijos por cada padre, ordenados por OrderColumn. Solo 1:N (requiere OrderColumn) y N:M
+	// (sin OrderColumn ordena por ChildKey); el resto de loaders devuelve error
+	PerParentLimit int
+}
+
+type relationConstraintsKey struct{}
+
+// WithRelationConstraints asocia restricciones a las relaciones que cargue Get, indexadas por su ruta
+// con puntos ("roles", "roles.permissions"). Las relaciones sin restricción se cargan igual que antes.
+// Solo aplican a la llamada a Get o LoadRelations que recibe el contexto: se retiran antes de ejecutar sus loaders
+func WithRelationConstraints(ctx context.Context, constraints map[string]RelationConstraint) context.Context {
+	return context.WithValue(ctx, relationConstraintsKey{}, constraints)
+}
+
+func relationConstraintsFrom(ctx context.Context) map[string]RelationConstraint {
+	constraints, _ := ctx.Value(relationConstraintsKey{}).(map[string]RelationConstraint)
+	return constraints
+}
+
+func (r *RelationConstraint) applyOptions(opts *models.Options, keyColumn string) {
+	if r.Columns != nil && len(*r.Columns) > 0 {
+		// La columna llave es necesaria para asignar cada hijo a su padre
+		columns := append([]string{}, *r.Columns...)
+		if keyColumn != "" && !containsColumn(columns, keyColumn) {
+			columns = append(columns, keyColumn)
+		}
+		opts.Columns = &columns
+	}
+
+	if r.OrderColumn != "" {
+		opts.OrderColumn = r.OrderColumn
+		opts.OrderDir = r.OrderDir
+	}
+}
+
+// Rechaza las restricciones que un loader no puede aplicar en lugar de ignorarlas
+func validateRelationConstraint(loadOpts *RelationLoadOptions) error {
+	if loadOpts == nil || loadOpts.Constraint == nil {
+		return nil
+	}
+
+	if loadOpts.Constraint.Limit > 0 {
+		return fmt.Errorf("relation constraint Limit is not supported for relation loads, use PerParentLimit")
+	}
+
+	return nil
+}
+
+// Para los loaders que no pueden limitar por padre: rechazan PerParentLimit en lugar de ignorarlo
+func unsupportedPerParentLimit(loadOpts *RelationLoadOptions) error {
+	if perParentLimit(loadOpts) > 0 {
+		return fmt.Errorf("relation constraint PerParentLimit is only supported by one-to-many and many-to-many relations")
+	}
+
+	return nil
+}
+
+func perParentLimit(loadOpts *RelationLoadOptions) int {
+	if loadOpts == nil || loadOpts.Constraint == nil {
+		return 0
+	}
+
+	return loadOpts.Constraint.PerParentLimit
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, col := range columns {
+		if strings.EqualFold(col, column) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package godbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRelationCallRemovesConstraintsFromContext(t *testing.T) {
+	ctx := WithRelationConstraints(context.Background(), map[string]RelationConstraint{
+		"roles": {Limit: 5},
+	})
+
+	stripped, call := takeRelationCall(ctx)
+
+	require.NotNil(t, call.constraintFor("roles"))
+	assert.Equal(t, 5, call.constraintFor("roles").Limit)
+	assert.True(t, relationCallFrom(stripped).isEmpty())
+	assert.False(t, relationCallFrom(ctx).isEmpty())
+}
+
+func TestRelationCallScope(t *testing.T) {
+	call := relationCall{constraints: map[string]RelationConstraint{
+		"roles":                  {Limit: 1},
+		"roles.permissions":      {Limit: 2},
+		"roles.permissions.tags": {Limit: 3},
+		"rolesets.items":         {Limit: 4},
+		"posts.comments":         {Limit: 5},
+	}}
+
+	scoped := call.scope("roles")
+
+	assert.Equal(t, map[string]RelationConstraint{
+		"permissions":      {Limit: 2},
+		"permissions.tags": {Limit: 3},
+	}, scoped.constraints)
+	assert.Nil(t, scoped.constraintFor("roles"))
+	assert.True(t, call.scope("users").isEmpty())
+}
+
+func TestRelationConstraintApplyOptions(t *testing.T) {
+	columns := []string{"id", "name"}
+	constraint := RelationConstraint{Columns: &columns, OrderColumn: "name", OrderDir: "DESC"}
+
+	opts := models.Options{}
+	constraint.applyOptions(&opts, "role_id")
+
+	require.NotNil(t, opts.Columns)
+	assert.Equal(t, []string{"id", "name", "role_id"}, *opts.Columns)
+	assert.Equal(t, []string{"id", "name"}, columns)
+	assert.Equal(t, "name", opts.OrderColumn)
+	assert.Equal(t, "DESC", opts.OrderDir)
+	assert.Zero(t, opts.Limit)
+}
+
+func TestValidateRelationConstraint(t *testing.T) {
+	assert.NoError(t, validateRelationConstraint(nil))
+	assert.NoError(t, validateRelationConstraint(&RelationLoadOptions{}))
+	assert.NoError(t, validateRelationConstraint(&RelationLoadOptions{Constraint: &RelationConstraint{PerParentLimit: 3}}))
+	assert.ErrorContains(t, validateRelationConstraint(&RelationLoadOptions{Constraint: &RelationConstraint{Limit: 1}}), "use PerParentLimit")
+}
+
+func TestLoaderRejectsRelationLimit(t *testing.T) {
+	profiles := &fakeRepository[*loaderTestProfile]{
+		table: "profiles",
+		rows:  []*loaderTestProfile{{Id: 10, UserId: 1}, {Id: 20, UserId: 2}, {Id: 30, UserId: 3}},
+	}
+	loader := &OnetoOneLoader[*loaderTestUser, *loaderTestProfile]{
+		Repository: profiles, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Profile",
+	}
+
+	users := []any{&loaderTestUser{Id: 1}, &loaderTestUser{Id: 2}, &loaderTestUser{Id: 3}}
+	err := loader.LoadWithOptions(context.Background(), users, nil, &RelationLoadOptions{Constraint: &RelationConstraint{Limit: 1}})
+
+	assert.ErrorContains(t, err, "Limit is not supported")
+	assert.Empty(t, profiles.gets)
+}
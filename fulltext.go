@@ -0,0 +1,121 @@
+package godbsql
+
+import (
+	"fmt"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+)
+
+// Filtro de búsqueda de texto completo: to_tsvector(Config, Key) @@ websearch_to_tsquery(Config, Query).
+// Vector reemplaza a to_tsvector(Key) cuando ya existe una columna o expresión tsvector.
+// Con OrderByRank, Get ordena por ts_rank descendente antes de opts.OrderColumn; con HeadlineField
+// escribe en ese campo del modelo el fragmento resaltado por ts_headline sobre la columna Key
+type FullTextFilter struct {
+	Key    string
+	Vector RawSQL
+	Config string
+	Query  string
+
+	OrderByRank     bool
+	HeadlineField   string
+	HeadlineOptions string
+}
+
+// Expresiones tsvector y tsquery del filtro, la configuración y la búsqueda van como parámetros.
+// config es el prefijo "$n::regconfig, " para reutilizarlo en otras funciones de texto completo
+func (f FullTextFilter) expressions(counter int) (config, vector, query string, args []any, newCounter int, err error) {
+	if f.Key == "" && f.Vector == "" {
+		return "", "", "", nil, counter, fmt.Errorf("full text filter requires a key or a vector")
+	}
+
+	if f.Key != "" {
+		if err := validateIdentifier(f.Key); err != nil {
+			return "", "", "", nil, counter, err
+		}
+	}
+
+	args = []any{}
+	if f.Config != "" {
+		config = fmt.Sprintf("$%d::regconfig, ", counter)
+		args = append(args, f.Config)
+		counter++
+	}
+
+	vector = f.Vector.String()
+	if vector == "" {
+		vector = fmt.Sprintf("to_tsvector(%s%s)", config, f.Key)
+	}
+
+	query = fmt.Sprintf("websearch_to_tsquery(%s$%d)", config, counter)
+	args = append(args, f.Query)
+	counter++
+
+	return config, vector, query, args, counter, nil
+}
+
+func prepareFullTextFilter(filter FullTextFilter, counter int) (string, []any, int, error) {
+	_, vector, query, args, counter, err := filter.expressions(counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	return fmt.Sprintf("%s @@ %s", vector, query), args, counter, nil
+}
+
+// Primer filtro de texto completo que pide ordenar por rango o un fragmento resaltado, en cualquier nivel
+func findFullTextSearch(filters models.GroupFilter) *FullTextFilter {
+	for _, tmpFilter := range filters.Filters {
+		switch filter := tmpFilter.(type) {
+		case FullTextFilter:
+			if filter.OrderByRank || filter.HeadlineField != "" {
+				return &filter
+			}
+		case models.GroupFilter:
+			if found := findFullTextSearch(filter); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// Columna extra "ts_headline(...) AS godbsql_headline" para el SELECT de Get
+func (f *FullTextFilter) headlineColumn(counter int) (string, []any, int, error) {
+	if f.Key == "" {
+		return "", nil, counter, fmt.Errorf("full text headline requires the text column in key")
+	}
+
+	config, _, query, args, counter, err := f.expressions(counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	options := ""
+	if f.HeadlineOptions != "" {
+		options = fmt.Sprintf(", $%d", counter)
+		args = append(args, f.HeadlineOptions)
+		counter++
+	}
+
+	return fmt.Sprintf("ts_headline(%s%s, %s%s) AS godbsql_headline", config, f.Key, query, options), args, counter, nil
+}
+
+func (f *FullTextFilter) rankExpression(counter int) (string, []any, int, error) {
+	_, vector, query, args, counter, err := f.expressions(counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	return fmt.Sprintf("ts_rank(%s, %s) DESC", vector, query), args, counter, nil
+}
+
+// Escanea la fila agregando destinos extra al final, para columnas calculadas que no son del modelo
+type extraColumnsScanner struct {
+	Scannable
+	extra []any
+}
+
+func (s extraColumnsScanner) Scan(dest ...any) error {
+	return s.Scannable.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,98 @@
+package godbsql
+
+import (
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareFullTextFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        FullTextFilter
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "columna sin configuración",
+			filter:        FullTextFilter{Key: "body", Query: "postgres"},
+			counter:       1,
+			expectedQuery: "to_tsvector(body) @@ websearch_to_tsquery($1)",
+			expectedArgs:  []any{"postgres"},
+			expectedNext:  2,
+		},
+		{
+			name:          "columna con configuración",
+			filter:        FullTextFilter{Key: "body", Config: "spanish", Query: "base de datos"},
+			counter:       3,
+			expectedQuery: "to_tsvector($3::regconfig, body) @@ websearch_to_tsquery($3::regconfig, $4)",
+			expectedArgs:  []any{"spanish", "base de datos"},
+			expectedNext:  5,
+		},
+		{
+			name:          "vector existente",
+			filter:        FullTextFilter{Vector: RawSQL("search_vector"), Config: "english", Query: "go"},
+			counter:       1,
+			expectedQuery: "search_vector @@ websearch_to_tsquery($1::regconfig, $2)",
+			expectedArgs:  []any{"english", "go"},
+			expectedNext:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareFullTextFilter(tt.filter, tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareFullTextFilterErrors(t *testing.T) {
+	_, _, _, err := prepareFullTextFilter(FullTextFilter{Query: "go"}, 1)
+	assert.Error(t, err)
+
+	_, _, _, err = prepareFullTextFilter(FullTextFilter{Key: "body) @@ to_tsquery('x'", Query: "go"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
+
+func TestFullTextHeadlineAndRank(t *testing.T) {
+	search := FullTextFilter{Key: "body", Config: "spanish", Query: "go", HeadlineOptions: "MaxWords=10"}
+
+	headline, args, next, err := search.headlineColumn(4)
+	require.NoError(t, err)
+	assert.Equal(t, "ts_headline($4::regconfig, body, websearch_to_tsquery($4::regconfig, $5), $6) AS godbsql_headline", headline)
+	assert.Equal(t, []any{"spanish", "go", "MaxWords=10"}, args)
+	assert.Equal(t, 7, next)
+
+	rank, args, next, err := search.rankExpression(next)
+	require.NoError(t, err)
+	assert.Equal(t, "ts_rank(to_tsvector($7::regconfig, body), websearch_to_tsquery($7::regconfig, $8)) DESC", rank)
+	assert.Equal(t, []any{"spanish", "go"}, args)
+	assert.Equal(t, 9, next)
+
+	_, _, _, err = (&FullTextFilter{Vector: RawSQL("search_vector"), Query: "go"}).headlineColumn(1)
+	assert.Error(t, err)
+}
+
+func TestFindFullTextSearch(t *testing.T) {
+	plain := FullTextFilter{Key: "title", Query: "a"}
+	ranked := FullTextFilter{Key: "body", Query: "b", OrderByRank: true}
+
+	filters := models.GroupFilter{Filters: []any{
+		plain,
+		models.GroupFilter{Filters: []any{models.Filter{Key: "status", Value: "x"}, ranked}},
+	}}
+
+	found := findFullTextSearch(filters)
+	require.NotNil(t, found)
+	assert.Equal(t, "body", found.Key)
+
+	assert.Nil(t, findFullTextSearch(models.GroupFilter{Filters: []any{plain}}))
+}
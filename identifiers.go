@@ -0,0 +1,115 @@
+package godbsql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Error base de los identificadores rechazados, permite usar errors.Is
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// Los identificadores se validan y se escriben sin comillas para conservar el plegado a minúsculas de Postgres.
+// Columna: columna, tabla.columna o esquema.tabla.columna. Tabla: tabla o esquema.tabla
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*){0,2}$`)
+	tableNamePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+)
+
+func validateIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+
+	return nil
+}
+
+func validateTableName(name string) error {
+	if !tableNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: invalid table name %q", ErrInvalidIdentifier, name)
+	}
+
+	return nil
+}
+
+// Lista de columnas de un SELECT, además de columnas acepta * y tabla.*
+func validateColumns(columns []string) error {
+	for _, col := range columns {
+		if col == "*" {
+			continue
+		}
+
+		if table, ok := strings.CutSuffix(col, ".*"); ok {
+			if err := validateTableName(table); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := validateIdentifier(col); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Llaves del mapa de datos de Create / Update, cada una es una columna
+func validateDataColumns(data map[string]any) error {
+	for col := range data {
+		if err := validateIdentifier(col); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateIdentifiers(names ...string) error {
+	for _, name := range names {
+		if err := validateIdentifier(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Configuración de los loaders que se escribe tal cual en el SQL. Se valida antes de armar cada consulta
+// porque los loaders se construyen como literales, sin pasar por un constructor
+func (l *OnetoManyLoader[P, C]) validateIdentifiers() error {
+	return validateIdentifiers(l.ChildFkField, fieldColumn(l.ParentField))
+}
+
+func (c *OnetoOneLoader[P, C]) validateIdentifiers() error {
+	return validateIdentifiers(c.ChildFkField, fieldColumn(c.ParentField))
+}
+
+func (b *BelongsToLoader[P, C]) validateIdentifiers() error {
+	return validateIdentifiers(b.ChildKey, fieldColumn(b.ParentFkField))
+}
+
+func (m *MorphManyLoader[P, C]) validateIdentifiers() error {
+	return validateIdentifiers(m.MorphIdField, m.MorphTypeField, fieldColumn(m.ParentField))
+}
+
+func (m *ManyToManyLoader[P, C]) validateIdentifiers() error {
+	if err := validateTableName(m.PivoteTable); err != nil {
+		return err
+	}
+
+	if err := validateIdentifiers(m.PivoteParentKey, m.PivoteChildKey, m.ChildKey, fieldColumn(m.ParentKey)); err != nil {
+		return err
+	}
+
+	return validateIdentifiers(m.PivoteColumns...)
+}
+
+func (h *HasManyThroughLoader[P, C]) validateIdentifiers() error {
+	if err := validateTableName(h.ThroughTable); err != nil {
+		return err
+	}
+
+	return validateIdentifiers(h.ThroughParentKey, h.ThroughKey, h.ChildFkField, fieldColumn(h.ParentKey))
+}
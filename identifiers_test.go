@@ -0,0 +1,150 @@
+package godbsql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "id", valid: true},
+		{name: "user_id", valid: true},
+		{name: "users.id", valid: true},
+		{name: "public.users.id", valid: true},
+		{name: "_private", valid: true},
+		{name: "", valid: false},
+		{name: "1id", valid: false},
+		{name: "id; DROP TABLE users", valid: false},
+		{name: "id--", valid: false},
+		{name: "a.b.c.d", valid: false},
+		{name: "\"id\"", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIdentifier(tt.name)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidIdentifier)
+			}
+		})
+	}
+}
+
+func TestValidateTableName(t *testing.T) {
+	assert.NoError(t, validateTableName("users"))
+	assert.NoError(t, validateTableName("public.users"))
+	assert.ErrorIs(t, validateTableName("public.users.id"), ErrInvalidIdentifier)
+	assert.ErrorIs(t, validateTableName("users u"), ErrInvalidIdentifier)
+}
+
+func TestValidateColumns(t *testing.T) {
+	assert.NoError(t, validateColumns([]string{"*", "users.*", "id", "users.name"}))
+	assert.ErrorIs(t, validateColumns([]string{"id", "count(*)"}), ErrInvalidIdentifier)
+	assert.ErrorIs(t, validateColumns([]string{"users u.*"}), ErrInvalidIdentifier)
+}
+
+func TestLoaderValidateIdentifiers(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader interface{ validateIdentifiers() error }
+		valid  bool
+	}{
+		{
+			name:   "1:N",
+			loader: &OnetoManyLoader[*aggregateTestModel, *aggregateTestModel]{ParentField: "Id", ChildFkField: "user_id"},
+			valid:  true,
+		},
+		{
+			name:   "1:N llave inválida",
+			loader: &OnetoManyLoader[*aggregateTestModel, *aggregateTestModel]{ParentField: "Id", ChildFkField: "user_id OR 1=1"},
+		},
+		{
+			name: "N:M",
+			loader: &ManyToManyLoader[*aggregateTestModel, *aggregateTestModel]{
+				ParentKey: "Id", ChildKey: "Id", PivoteTable: "role_users", PivoteParentKey: "user_id", PivoteChildKey: "role_id",
+				PivoteColumns: []string{"expires_at"},
+			},
+			valid: true,
+		},
+		{
+			name: "N:M tabla pivote inválida",
+			loader: &ManyToManyLoader[*aggregateTestModel, *aggregateTestModel]{
+				ParentKey: "Id", ChildKey: "Id", PivoteTable: "role_users; DELETE FROM users", PivoteParentKey: "user_id", PivoteChildKey: "role_id",
+			},
+		},
+		{
+			name: "N:M columna pivote inválida",
+			loader: &ManyToManyLoader[*aggregateTestModel, *aggregateTestModel]{
+				ParentKey: "Id", ChildKey: "Id", PivoteTable: "role_users", PivoteParentKey: "user_id", PivoteChildKey: "role_id",
+				PivoteColumns: []string{"(SELECT password FROM users)"},
+			},
+		},
+		{
+			name: "a través de",
+			loader: &HasManyThroughLoader[*aggregateTestModel, *aggregateTestModel]{
+				ParentKey: "Id", ThroughTable: "posts", ThroughParentKey: "user_id", ThroughKey: "id", ChildFkField: "post_id",
+			},
+			valid: true,
+		},
+		{
+			name: "a través de llave inválida",
+			loader: &HasManyThroughLoader[*aggregateTestModel, *aggregateTestModel]{
+				ParentKey: "Id", ThroughTable: "posts", ThroughParentKey: "user_id", ThroughKey: "id)", ChildFkField: "post_id",
+			},
+		},
+		{
+			name:   "polimórfica inválida",
+			loader: &MorphManyLoader[*aggregateTestModel, *aggregateTestModel]{ParentField: "Id", MorphIdField: "commentable_id", MorphTypeField: "type'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.loader.validateIdentifiers()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidIdentifier)
+			}
+		})
+	}
+}
+
+func TestConnectionRejectsInvalidTable(t *testing.T) {
+	conn := &Connection[*aggregateTestModel]{Table: "users; DROP TABLE users"}
+	ctx := context.Background()
+	filters := models.GroupFilter{Filters: []any{}}
+
+	_, err := conn.Get(ctx, filters, nil)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	_, err = conn.Create(ctx, map[string]any{"name": "x"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	_, err = conn.Update(ctx, filters, map[string]any{"name": "x"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	assert.ErrorIs(t, conn.Delete(ctx, filters), ErrInvalidIdentifier)
+
+	_, err = conn.Count(ctx, filters)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
+
+func TestWhereHasRejectsInvalidLoaderKeys(t *testing.T) {
+	scope := &filterScope{table: "users", relations: map[string]repository.RelationLoader{
+		"posts": &OnetoManyLoader[*aggregateTestModel, *aggregateTestModel]{ParentField: "Id", ChildFkField: "user_id = users.id OR true"},
+	}}
+
+	_, _, _, err := compileWhereHas("posts", nil, false, scope, 1)
+
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
@@ -0,0 +1,162 @@
+package godbsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ComparatorJSONContains    = "@>"
+	ComparatorJSONContainedBy = "<@"
+	ComparatorJSONHasKey      = "?"
+	ComparatorJSONHasAnyKeys  = "?|"
+	ComparatorJSONHasAllKeys  = "?&"
+	ComparatorJSONPathExists  = "JSONB_PATH_EXISTS"
+)
+
+// Filtro sobre una columna jsonb.
+//   - Path vacío compara el documento completo; con Path se compara el valor en esa ruta
+//     (metadata->>'a' con un elemento, metadata#>>'{a,b}' con varios).
+//   - Con los comparadores de models.Filter (=, LIKE, BETWEEN, ...) el valor de la ruta se compara como texto.
+//   - Con @> y <@ el valor se convierte a JSON; ?, ?| y ?& reciben una llave o un slice de llaves.
+//   - JSONB_PATH_EXISTS recibe la expresión jsonpath en Value y sus variables opcionales en PathVars.
+type JSONFilter struct {
+	Key        string
+	Path       []string
+	Comparator *string
+	Value      any
+	PathVars   map[string]any
+}
+
+func isJSONComparator(comparator string) bool {
+	switch comparator {
+	case ComparatorJSONContains, ComparatorJSONContainedBy, ComparatorJSONHasKey, ComparatorJSONHasAnyKeys, ComparatorJSONHasAllKeys, ComparatorJSONPathExists:
+		return true
+	}
+
+	return false
+}
+
+func prepareJSONFilter(filter JSONFilter, counter int) (string, []any, int, error) {
+	comparator := ComparatorEqual
+	if filter.Comparator != nil {
+		comparator = normalizeComparator(*filter.Comparator)
+	}
+
+	if err := validateIdentifier(filter.Key); err != nil {
+		return "", nil, counter, err
+	}
+
+	// Los comparadores JSON trabajan sobre jsonb (-> / #>), el resto sobre el texto de la ruta (->> / #>>)
+	asJSON := isJSONComparator(comparator)
+	target, vals, counter := jsonPathExpression(filter.Key, filter.Path, asJSON, counter)
+
+	if !asJSON {
+		comparison, comparisonVals, newCounter, err := prepareComparison(target, comparator, filter.Value, counter)
+		if err != nil {
+			return "", nil, counter, err
+		}
+
+		return comparison, append(vals, comparisonVals...), newCounter, nil
+	}
+
+	switch comparator {
+	case ComparatorJSONContains, ComparatorJSONContainedBy:
+		document, err := jsonParam(filter.Value)
+		if err != nil {
+			return "", nil, counter, err
+		}
+
+		return fmt.Sprintf("%s %s $%d::jsonb", target, comparator, counter), append(vals, document), counter + 1, nil
+	case ComparatorJSONHasKey:
+		key, ok := filter.Value.(string)
+		if !ok {
+			return "", nil, counter, fmt.Errorf("comparator %s requires a string key", comparator)
+		}
+
+		return fmt.Sprintf("%s ? $%d", target, counter), append(vals, key), counter + 1, nil
+	case ComparatorJSONHasAnyKeys, ComparatorJSONHasAllKeys:
+		keys, err := jsonKeys(filter.Value)
+		if err != nil {
+			return "", nil, counter, fmt.Errorf("comparator %s: %w", comparator, err)
+		}
+
+		return fmt.Sprintf("%s %s $%d::text[]", target, comparator, counter), append(vals, pq.Array(keys)), counter + 1, nil
+	}
+
+	// JSONB_PATH_EXISTS
+	path, ok := filter.Value.(string)
+	if !ok || path == "" {
+		return "", nil, counter, fmt.Errorf("comparator %s requires a jsonpath expression", comparator)
+	}
+
+	if len(filter.PathVars) == 0 {
+		return fmt.Sprintf("jsonb_path_exists(%s, $%d::jsonpath)", target, counter), append(vals, path), counter + 1, nil
+	}
+
+	pathVars, err := jsonParam(filter.PathVars)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	return fmt.Sprintf("jsonb_path_exists(%s, $%d::jsonpath, $%d::jsonb)", target, counter, counter+1), append(vals, path, pathVars), counter + 2, nil
+}
+
+// Expresión de la columna en la ruta indicada; los elementos de la ruta van como parámetros
+func jsonPathExpression(key string, path []string, asJSON bool, counter int) (string, []any, int) {
+	switch {
+	case len(path) == 0:
+		return key, nil, counter
+	case len(path) == 1:
+		operator := "->>"
+		if asJSON {
+			operator = "->"
+		}
+		return fmt.Sprintf("(%s%s$%d::text)", key, operator, counter), []any{path[0]}, counter + 1
+	}
+
+	operator := "#>>"
+	if asJSON {
+		operator = "#>"
+	}
+	return fmt.Sprintf("(%s%s$%d::text[])", key, operator, counter), []any{pq.Array(path)}, counter + 1
+}
+
+// Convierte el valor a un parámetro JSON; solo []byte y json.RawMessage se toman como JSON ya serializado,
+// un string se serializa como cadena JSON ("admin" -> "\"admin\"")
+func jsonParam(value any) (string, error) {
+	switch v := value.(type) {
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal json filter value: %w", err)
+	}
+
+	return string(document), nil
+}
+
+func jsonKeys(value any) ([]string, error) {
+	val := reflect.ValueOf(value)
+	if !val.IsValid() || (val.Kind() != reflect.Slice && val.Kind() != reflect.Array) {
+		return nil, fmt.Errorf("requires a slice of keys")
+	}
+
+	keys := make([]string, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		key, ok := val.Index(i).Interface().(string)
+		if !ok {
+			return nil, fmt.Errorf("keys must be strings")
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
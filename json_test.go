@@ -0,0 +1,152 @@
+package godbsql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONParam(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{name: "string", value: "admin", expected: `"admin"`},
+		{name: "string con forma de JSON", value: `{"a":1}`, expected: `"{\"a\":1}"`},
+		{name: "número", value: 10, expected: `10`},
+		{name: "mapa", value: map[string]any{"notifications": true}, expected: `{"notifications":true}`},
+		{name: "slice", value: []string{"a", "b"}, expected: `["a","b"]`},
+		{name: "[]byte", value: []byte(`{"a":1}`), expected: `{"a":1}`},
+		{name: "json.RawMessage", value: json.RawMessage(`["x"]`), expected: `["x"]`},
+		{name: "nil", value: nil, expected: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := jsonParam(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, document)
+		})
+	}
+}
+
+func TestPrepareJSONFilter(t *testing.T) {
+	contains := ComparatorJSONContains
+	containedBy := ComparatorJSONContainedBy
+	hasKey := ComparatorJSONHasKey
+	hasAnyKeys := ComparatorJSONHasAnyKeys
+	hasAllKeys := ComparatorJSONHasAllKeys
+	pathExists := ComparatorJSONPathExists
+	like := ComparatorLike
+
+	tests := []struct {
+		name          string
+		filter        JSONFilter
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "un elemento de ruta",
+			filter:        JSONFilter{Key: "metadata", Path: []string{"plan"}, Value: "pro"},
+			counter:       1,
+			expectedQuery: "(metadata->>$1::text) = $2",
+			expectedArgs:  []any{"plan", "pro"},
+			expectedNext:  3,
+		},
+		{
+			name:          "ruta anidada con LIKE",
+			filter:        JSONFilter{Key: "metadata", Path: []string{"address", "city"}, Comparator: &like, Value: "Mon%"},
+			counter:       3,
+			expectedQuery: "(metadata#>>$3::text[]) LIKE $4",
+			expectedArgs:  []any{pq.Array([]string{"address", "city"}), "Mon%"},
+			expectedNext:  5,
+		},
+		{
+			name:          "contiene un string",
+			filter:        JSONFilter{Key: "roles", Comparator: &contains, Value: "admin"},
+			counter:       1,
+			expectedQuery: "roles @> $1::jsonb",
+			expectedArgs:  []any{`"admin"`},
+			expectedNext:  2,
+		},
+		{
+			name:          "contenido en la ruta",
+			filter:        JSONFilter{Key: "settings", Path: []string{"flags"}, Comparator: &containedBy, Value: map[string]bool{"beta": true}},
+			counter:       2,
+			expectedQuery: "(settings->$2::text) <@ $3::jsonb",
+			expectedArgs:  []any{"flags", `{"beta":true}`},
+			expectedNext:  4,
+		},
+		{
+			name:          "tiene llave",
+			filter:        JSONFilter{Key: "settings", Comparator: &hasKey, Value: "theme"},
+			counter:       1,
+			expectedQuery: "settings ? $1",
+			expectedArgs:  []any{"theme"},
+			expectedNext:  2,
+		},
+		{
+			name:          "tiene alguna llave",
+			filter:        JSONFilter{Key: "settings", Comparator: &hasAnyKeys, Value: []string{"a", "b"}},
+			counter:       1,
+			expectedQuery: "settings ?| $1::text[]",
+			expectedArgs:  []any{pq.Array([]string{"a", "b"})},
+			expectedNext:  2,
+		},
+		{
+			name:          "tiene todas las llaves",
+			filter:        JSONFilter{Key: "settings", Comparator: &hasAllKeys, Value: []any{"a", "b"}},
+			counter:       1,
+			expectedQuery: "settings ?& $1::text[]",
+			expectedArgs:  []any{pq.Array([]string{"a", "b"})},
+			expectedNext:  2,
+		},
+		{
+			name:          "jsonpath con variables",
+			filter:        JSONFilter{Key: "metadata", Comparator: &pathExists, Value: "$.items[*] ? (@.price > $min)", PathVars: map[string]any{"min": 100}},
+			counter:       4,
+			expectedQuery: "jsonb_path_exists(metadata, $4::jsonpath, $5::jsonb)",
+			expectedArgs:  []any{"$.items[*] ? (@.price > $min)", `{"min":100}`},
+			expectedNext:  6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareJSONFilter(tt.filter, tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareJSONFilterErrors(t *testing.T) {
+	hasKey := ComparatorJSONHasKey
+	hasAnyKeys := ComparatorJSONHasAnyKeys
+	pathExists := ComparatorJSONPathExists
+
+	tests := []struct {
+		name   string
+		filter JSONFilter
+	}{
+		{name: "columna inválida", filter: JSONFilter{Key: "metadata; DROP TABLE users", Value: "x"}},
+		{name: "llave que no es string", filter: JSONFilter{Key: "settings", Comparator: &hasKey, Value: 1}},
+		{name: "llaves que no son slice", filter: JSONFilter{Key: "settings", Comparator: &hasAnyKeys, Value: "a"}},
+		{name: "jsonpath vacío", filter: JSONFilter{Key: "metadata", Comparator: &pathExists, Value: ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := prepareJSONFilter(tt.filter, 1)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package godbsql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var uuidType = reflect.TypeOf(uuid.UUID{})
+
+// Normaliza una llave para comparar padres e hijos sin importar el tipo con que se escaneó:
+// enteros de cualquier tamaño a int64, []byte a string, uuid.UUID y UUID en texto a su forma canónica.
+// Los punteros se desreferencian, un driver.Valuer (sql.NullInt64, uuid.NullUUID...) se compara por su valor
+// y un puntero nulo o un Valuer con Valid=false se normalizan a nil
+func normalizeKey(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	if val.Type() == uuidType {
+		return val.Interface().(uuid.UUID).String()
+	}
+
+	if driverValue, ok := valuerValue(val); ok {
+		return normalizeKey(driverValue)
+	}
+
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val.Uint() <= math.MaxInt64 {
+			return int64(val.Uint())
+		}
+		return val.Uint()
+	case reflect.String:
+		return normalizeStringKey(val.String())
+	case reflect.Slice:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return normalizeStringKey(string(val.Bytes()))
+		}
+	}
+
+	if val.Type().Comparable() {
+		return val.Interface()
+	}
+
+	return fmt.Sprintf("%v", val.Interface())
+}
+
+// Igual que normalizeKey, pero ajustada al tipo del campo del modelo con que se compara la llave (like):
+// 16 bytes crudos se leen como UUID si like es uuid.UUID, un entero en texto se compara como entero si like
+// es entero y un entero como texto si like es string
+func normalizeKeyAs(value any, like reflect.Type) any {
+	for like != nil && like.Kind() == reflect.Ptr {
+		like = like.Elem()
+	}
+
+	if like == nil {
+		return normalizeKey(value)
+	}
+
+	if like == uuidType {
+		if bytesValue, ok := value.([]byte); ok && len(bytesValue) == 16 {
+			if id, err := uuid.FromBytes(bytesValue); err == nil {
+				return id.String()
+			}
+		}
+	}
+
+	key := normalizeKey(value)
+	switch like.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if text, ok := key.(string); ok {
+			if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return number
+			}
+		}
+	case reflect.String:
+		if number, ok := key.(int64); ok {
+			return strconv.FormatInt(number, 10)
+		}
+	}
+
+	return key
+}
+
+// Tipo del campo field en el modelo M (struct o puntero a struct), nil si no existe
+func modelFieldType[M any](field string) reflect.Type {
+	modelType := reflect.TypeOf((*M)(nil)).Elem()
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	if modelType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	structField, ok := modelType.FieldByName(field)
+	if !ok {
+		return nil
+	}
+
+	return structField.Type
+}
+
+// Valor que un driver.Valuer (sql.NullInt64, sql.NullString, uuid.NullUUID...) envía a la base de datos,
+// nil si no es válido. uuid.UUID no se desenvuelve; ok es false si val no es un Valuer
+func valuerValue(val reflect.Value) (any, bool) {
+	if !val.IsValid() || val.Type() == uuidType {
+		return nil, false
+	}
+
+	valuer, ok := val.Interface().(driver.Valuer)
+	if !ok && val.CanAddr() {
+		valuer, ok = val.Addr().Interface().(driver.Valuer)
+	}
+	if !ok {
+		return nil, false
+	}
+
+	driverValue, err := valuer.Value()
+	if err != nil {
+		return nil, false
+	}
+
+	return driverValue, true
+}
+
+func normalizeStringKey(value string) string {
+	if len(value) == 36 {
+		if id, err := uuid.Parse(value); err == nil {
+			return id.String()
+		}
+	}
+
+	return value
+}
+
+// Elimina llaves repetidas (según normalizeKey) conservando el orden y el valor original
+func uniqueKeys(values []any) []any {
+	result := make([]any, 0, len(values))
+	seen := make(map[any]bool, len(values))
+	for _, value := range values {
+		key := normalizeKey(value)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		result = append(result, value)
+	}
+
+	return result
+}
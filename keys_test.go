@@ -0,0 +1,83 @@
+package godbsql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	id := uuid.MustParse("0190a6d8-6f2b-7c3e-9a41-2f5d8e7b1c00")
+	var nilPointer *int64
+
+	tests := []struct {
+		name  string
+		left  any
+		right any
+	}{
+		{name: "int32/int64", left: int32(42), right: int64(42)},
+		{name: "int/uint8", left: 7, right: uint8(7)},
+		{name: "puntero/valor", left: &id, right: id},
+		{name: "string/[]byte", left: "abcdefghijklmnop", right: []byte("abcdefghijklmnop")},
+		{name: "uuid.UUID/string", left: id, right: id.String()},
+		{name: "uuid.UUID/string en mayúsculas", left: id, right: "0190A6D8-6F2B-7C3E-9A41-2F5D8E7B1C00"},
+		{name: "uuid.UUID/[]byte en texto", left: id, right: []byte(id.String())},
+		{name: "puntero nulo/nil", left: nilPointer, right: nil},
+		{name: "sql.NullInt64/int64", left: sql.NullInt64{Int64: 2, Valid: true}, right: int64(2)},
+		{name: "*sql.NullInt64/int", left: &sql.NullInt64{Int64: 2, Valid: true}, right: 2},
+		{name: "sql.NullString/string", left: sql.NullString{String: "a", Valid: true}, right: "a"},
+		{name: "uuid.NullUUID/uuid.UUID", left: uuid.NullUUID{UUID: id, Valid: true}, right: id},
+		{name: "uuid.NullUUID/string", left: uuid.NullUUID{UUID: id, Valid: true}, right: id.String()},
+		{name: "sql.NullInt64 inválido/nil", left: sql.NullInt64{Int64: 2}, right: nil},
+		{name: "uuid.NullUUID inválido/nil", left: uuid.NullUUID{UUID: id}, right: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, normalizeKey(tt.left), normalizeKey(tt.right))
+		})
+	}
+}
+
+func TestNormalizeKeyDistinct(t *testing.T) {
+	assert.NotEqual(t, normalizeKey(int64(1)), normalizeKey("1"))
+	assert.NotEqual(t, normalizeKey("abc"), normalizeKey("ABC"))
+	assert.Equal(t, "abcdefghijklmnop", normalizeKey([]byte("abcdefghijklmnop")))
+}
+
+func TestNormalizeKeyAs(t *testing.T) {
+	id := uuid.MustParse("0190a6d8-6f2b-7c3e-9a41-2f5d8e7b1c00")
+	raw := id[:]
+
+	tests := []struct {
+		name     string
+		value    any
+		like     reflect.Type
+		expected any
+	}{
+		{name: "16 bytes contra uuid.UUID", value: raw, like: reflect.TypeOf(id), expected: id.String()},
+		{name: "16 bytes contra *uuid.UUID", value: raw, like: reflect.TypeOf(&id), expected: id.String()},
+		{name: "16 bytes contra string", value: []byte("abcdefghijklmnop"), like: reflect.TypeOf(""), expected: "abcdefghijklmnop"},
+		{name: "16 bytes sin tipo", value: []byte("abcdefghijklmnop"), like: nil, expected: "abcdefghijklmnop"},
+		{name: "entero contra int", value: int32(5), like: reflect.TypeOf(0), expected: int64(5)},
+		{name: "texto contra int64", value: "5", like: reflect.TypeOf(int64(0)), expected: int64(5)},
+		{name: "texto no numérico contra int64", value: "cinco", like: reflect.TypeOf(int64(0)), expected: "cinco"},
+		{name: "entero contra string", value: int64(5), like: reflect.TypeOf(""), expected: "5"},
+		{name: "UUID en texto contra uuid.UUID", value: []byte(id.String()), like: reflect.TypeOf(id), expected: id.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeKeyAs(tt.value, tt.like))
+		})
+	}
+}
+
+func TestUniqueKeys(t *testing.T) {
+	keys := uniqueKeys([]any{int32(1), int64(1), "a", []byte("a"), nil, nil, 2})
+
+	assert.Equal(t, []any{int32(1), "a", nil, 2}, keys)
+}
@@ -0,0 +1,341 @@
+package godbsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/goenvars"
+	"github.com/Nemutagk/golog"
+)
+
+// Resultado de una operación sobre la tabla pivote
+type PivotChanges struct {
+	Attached []any
+	Detached []any
+}
+
+// Attach agrega a la tabla pivote los hijos que aún no estén relacionados con el padre. Attached solo incluye
+// las filas que realmente se insertaron, aunque otra petición haya agregado las mismas al mismo tiempo
+func (m *ManyToManyLoader[P, C]) Attach(ctx context.Context, parentId any, childIds []any, tx *models.Transaction) (PivotChanges, error) {
+	return m.inPivotTransaction(ctx, tx, func(exec sqlExecutor) (PivotChanges, error) {
+		existing, err := m.pivotChildIds(ctx, exec, parentId)
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		attached, err := m.insertPivotRows(ctx, exec, parentId, diffPivotIds(childIds, existing, m.pivotChildKey))
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		return PivotChanges{Attached: attached, Detached: []any{}}, nil
+	})
+}
+
+// Detach elimina de la tabla pivote la relación del padre con los hijos indicados
+func (m *ManyToManyLoader[P, C]) Detach(ctx context.Context, parentId any, childIds []any, tx *models.Transaction) (PivotChanges, error) {
+	if len(childIds) == 0 {
+		return PivotChanges{Attached: []any{}, Detached: []any{}}, nil
+	}
+
+	return m.inPivotTransaction(ctx, tx, func(exec sqlExecutor) (PivotChanges, error) {
+		detached, err := m.deletePivotRows(ctx, exec, parentId, childIds)
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		return PivotChanges{Attached: []any{}, Detached: detached}, nil
+	})
+}
+
+// DetachAll elimina todas las relaciones del padre en la tabla pivote
+func (m *ManyToManyLoader[P, C]) DetachAll(ctx context.Context, parentId any, tx *models.Transaction) (PivotChanges, error) {
+	return m.inPivotTransaction(ctx, tx, func(exec sqlExecutor) (PivotChanges, error) {
+		detached, err := m.deletePivotRows(ctx, exec, parentId, nil)
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		return PivotChanges{Attached: []any{}, Detached: detached}, nil
+	})
+}
+
+// Sync deja al padre relacionado exactamente con los hijos indicados, insertando y eliminando solo la diferencia
+func (m *ManyToManyLoader[P, C]) Sync(ctx context.Context, parentId any, childIds []any, tx *models.Transaction) (PivotChanges, error) {
+	return m.inPivotTransaction(ctx, tx, func(exec sqlExecutor) (PivotChanges, error) {
+		existing, err := m.pivotChildIds(ctx, exec, parentId)
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		desired := map[any]bool{}
+		for _, id := range childIds {
+			desired[m.pivotChildKey(id)] = true
+		}
+
+		toDetach := []any{}
+		for key, id := range existing {
+			if !desired[key] {
+				toDetach = append(toDetach, id)
+			}
+		}
+
+		changes := PivotChanges{Attached: []any{}, Detached: []any{}}
+
+		if len(toDetach) > 0 {
+			detached, err := m.deletePivotRows(ctx, exec, parentId, toDetach)
+			if err != nil {
+				return PivotChanges{}, err
+			}
+			changes.Detached = detached
+		}
+
+		attached, err := m.insertPivotRows(ctx, exec, parentId, diffPivotIds(childIds, existing, m.pivotChildKey))
+		if err != nil {
+			return PivotChanges{}, err
+		}
+		changes.Attached = attached
+
+		return changes, nil
+	})
+}
+
+// Ejecuta fn dentro de tx; sin transacción externa abre una propia para que la operación sea atómica
+func (m *ManyToManyLoader[P, C]) inPivotTransaction(ctx context.Context, tx *models.Transaction, fn func(exec sqlExecutor) (PivotChanges, error)) (PivotChanges, error) {
+	if err := m.validateIdentifiers(); err != nil {
+		return PivotChanges{}, err
+	}
+
+	if tx != nil {
+		exec, err := resolveExecutor(m.Connection, tx)
+		if err != nil {
+			return PivotChanges{}, err
+		}
+
+		return fn(exec)
+	}
+
+	sqlConn, ok := m.Connection.(*sql.DB)
+	if !ok {
+		return PivotChanges{}, fmt.Errorf("failed to assert connection to *sql.DB")
+	}
+
+	sqlTx, err := sqlConn.BeginTx(ctx, nil)
+	if err != nil {
+		return PivotChanges{}, fmt.Errorf("failed to start pivot transaction: %w", err)
+	}
+
+	changes, err := fn(sqlTx)
+	if err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			golog.Log(ctx, "failed to rollback pivot transaction:", rbErr)
+		}
+		return PivotChanges{}, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return PivotChanges{}, fmt.Errorf("failed to commit pivot transaction: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (m *ManyToManyLoader[P, C]) pivotChildIds(ctx context.Context, exec sqlExecutor, parentId any) (map[any]any, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", m.PivoteChildKey, m.PivoteTable, m.PivoteParentKey)
+
+	if goenvars.GetEnvBool("SQL_DEBUG", false) {
+		golog.Log(ctx, "SQL Query:", query)
+		golog.Log(ctx, "SQL Values:", parentId)
+	}
+
+	rows, err := exec.QueryContext(ctx, query, parentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pivot table: %w", err)
+	}
+	defer rows.Close()
+
+	existing := map[any]any{}
+	for rows.Next() {
+		var childId any
+		if err := rows.Scan(&childId); err != nil {
+			return nil, fmt.Errorf("failed to scan pivot row: %w", err)
+		}
+
+		existing[m.pivotChildKey(childId)] = childId
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return existing, nil
+}
+
+// Inserta las filas pivote ignorando las que ya existan (ON CONFLICT DO NOTHING) y devuelve, en el orden
+// recibido, los hijos que realmente se insertaron
+func (m *ManyToManyLoader[P, C]) insertPivotRows(ctx context.Context, exec sqlExecutor, parentId any, childIds []any) ([]any, error) {
+	if len(childIds) == 0 {
+		return []any{}, nil
+	}
+
+	columns := []string{m.PivoteParentKey, m.PivoteChildKey}
+	if m.PivoteTimestamps {
+		columns = append(columns, "created_at", "updated_at")
+	}
+
+	// Cada fila usa len(columns) parámetros; se parte en lotes para no rebasar el límite de Postgres
+	batchSize := maxQueryParams / len(columns)
+	now := time.Now().UTC()
+	inserted := map[any]bool{}
+	for start := 0; start < len(childIds); start += batchSize {
+		end := min(start+batchSize, len(childIds))
+		if err := m.insertPivotBatch(ctx, exec, parentId, childIds[start:end], columns, now, inserted); err != nil {
+			return nil, err
+		}
+	}
+
+	attached := []any{}
+	for _, childId := range childIds {
+		if inserted[m.pivotChildKey(childId)] {
+			attached = append(attached, childId)
+		}
+	}
+
+	return attached, nil
+}
+
+func (m *ManyToManyLoader[P, C]) insertPivotBatch(ctx context.Context, exec sqlExecutor, parentId any, childIds []any, columns []string, now time.Time, inserted map[any]bool) error {
+	values := make([]any, 0, len(childIds)*len(columns))
+	rowsPlaceholders := make([]string, 0, len(childIds))
+	counter := 1
+	for _, childId := range childIds {
+		rowValues := []any{parentId, childId}
+		if m.PivoteTimestamps {
+			rowValues = append(rowValues, now, now)
+		}
+
+		placeholders := make([]string, 0, len(rowValues))
+		for _, v := range rowValues {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", counter))
+			values = append(values, v)
+			counter++
+		}
+
+		rowsPlaceholders = append(rowsPlaceholders, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT DO NOTHING RETURNING %s",
+		m.PivoteTable,
+		strings.Join(columns, ", "),
+		strings.Join(rowsPlaceholders, ", "),
+		m.PivoteChildKey,
+	)
+
+	if goenvars.GetEnvBool("SQL_DEBUG", false) {
+		golog.Log(ctx, "SQL Query:", query)
+		golog.Log(ctx, "SQL Values:", values)
+	}
+
+	rows, err := exec.QueryContext(ctx, query, values...)
+	if err != nil {
+		return fmt.Errorf("failed to insert pivot rows: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var childId any
+		if err := rows.Scan(&childId); err != nil {
+			return fmt.Errorf("failed to scan pivot row: %w", err)
+		}
+
+		inserted[m.pivotChildKey(childId)] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	return nil
+}
+
+// Elimina las filas pivote del padre; si childIds es nil elimina todas. Devuelve los hijos eliminados
+func (m *ManyToManyLoader[P, C]) deletePivotRows(ctx context.Context, exec sqlExecutor, parentId any, childIds []any) ([]any, error) {
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(fmt.Sprintf("DELETE FROM %s WHERE %s = $1", m.PivoteTable, m.PivoteParentKey))
+
+	values := []any{parentId}
+	if childIds != nil {
+		inQuery, inVals, _ := inClause(m.PivoteChildKey, ComparatorIn, childIds, 2)
+		queryBuilder.WriteString(" AND ")
+		queryBuilder.WriteString(inQuery)
+		values = append(values, inVals...)
+	}
+
+	queryBuilder.WriteString(" RETURNING ")
+	queryBuilder.WriteString(m.PivoteChildKey)
+
+	query := queryBuilder.String()
+	if goenvars.GetEnvBool("SQL_DEBUG", false) {
+		golog.Log(ctx, "SQL Query:", query)
+		golog.Log(ctx, "SQL Values:", values)
+	}
+
+	rows, err := exec.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete pivot rows: %w", err)
+	}
+	defer rows.Close()
+
+	detached := []any{}
+	seen := map[any]bool{}
+	for rows.Next() {
+		var childId any
+		if err := rows.Scan(&childId); err != nil {
+			return nil, fmt.Errorf("failed to scan pivot row: %w", err)
+		}
+
+		key := m.pivotChildKey(childId)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		if bytesId, ok := childId.([]byte); ok {
+			childId = string(bytesId)
+		}
+		detached = append(detached, childId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return detached, nil
+}
+
+// Llave con que se comparan los hijos pedidos con los escaneados de la tabla pivote, ajustada al tipo del
+// campo ChildKey del modelo hijo: "5" y 5 son el mismo hijo si ChildKey es entero o string
+func (m *ManyToManyLoader[P, C]) pivotChildKey(childId any) any {
+	return normalizeKeyAs(childId, modelFieldType[C](m.ChildKey))
+}
+
+// Hijos de la lista que no están en existing (indexado con key), sin repetir
+func diffPivotIds(childIds []any, existing map[any]any, key func(any) any) []any {
+	result := []any{}
+	seen := map[any]bool{}
+	for _, id := range childIds {
+		childKey := key(id)
+		if _, exists := existing[childKey]; exists || seen[childKey] {
+			continue
+		}
+
+		seen[childKey] = true
+		result = append(result, id)
+	}
+
+	return result
+}
@@ -0,0 +1,302 @@
+package godbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tabla pivote falsa: el SELECT devuelve existing y el INSERT ... RETURNING devuelve los hijos de returning
+// (o todos los insertados si returning es nil); el DELETE ... RETURNING devuelve deleted
+type fakePivot struct {
+	existing  []driver.Value
+	returning []driver.Value
+	deleted   []driver.Value
+	insertErr error
+}
+
+func (p *fakePivot) handle(query string, args []any) fakeResult {
+	switch {
+	case strings.HasPrefix(query, "SELECT"):
+		return fakeResult{columns: []string{"role_id"}, rows: pivotRows(p.existing)}
+	case strings.HasPrefix(query, "INSERT"):
+		if p.insertErr != nil {
+			return fakeResult{err: p.insertErr}
+		}
+
+		if p.returning != nil {
+			return fakeResult{columns: []string{"role_id"}, rows: pivotRows(p.returning)}
+		}
+
+		// Sin conflicto se insertan todos: el segundo valor de cada fila es el hijo
+		columns := 2
+		if strings.Contains(query, "created_at") {
+			columns = 4
+		}
+
+		inserted := []driver.Value{}
+		for i := 1; i < len(args); i += columns {
+			inserted = append(inserted, args[i])
+		}
+		return fakeResult{columns: []string{"role_id"}, rows: pivotRows(inserted)}
+	case strings.HasPrefix(query, "DELETE"):
+		return fakeResult{columns: []string{"role_id"}, rows: pivotRows(p.deleted)}
+	}
+
+	return fakeResult{err: errFakeNotImplemented}
+}
+
+func pivotRows(values []driver.Value) [][]driver.Value {
+	rows := make([][]driver.Value, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, []driver.Value{value})
+	}
+
+	return rows
+}
+
+func newPivotTestLoader(pivot *fakePivot) (*ManyToManyLoader[*loaderTestUser, *loaderTestRole], *fakeDB) {
+	conn, db := newFakeDB(pivot.handle)
+
+	return &ManyToManyLoader[*loaderTestUser, *loaderTestRole]{
+		Connection: conn, ParentKey: "Id", ChildKey: "Id", PivoteTable: "role_users",
+		PivoteParentKey: "user_id", PivoteChildKey: "role_id", ContainerField: "Roles",
+	}, db
+}
+
+func TestDiffPivotIds(t *testing.T) {
+	existing := map[any]any{int64(1): int64(1)}
+
+	tests := []struct {
+		name     string
+		childIds []any
+		key      func(any) any
+		expected []any
+	}{
+		{name: "sin existentes ni repetidos", childIds: []any{2, 3}, key: normalizeKey, expected: []any{2, 3}},
+		{name: "omite existentes y repetidos", childIds: []any{1, 2, int32(2), 3}, key: normalizeKey, expected: []any{2, 3}},
+		{name: "texto sin ajustar al tipo", childIds: []any{"1"}, key: normalizeKey, expected: []any{"1"}},
+		{
+			name:     "texto ajustado al tipo del hijo",
+			childIds: []any{"1", "2", 2},
+			key:      (&ManyToManyLoader[*loaderTestUser, *loaderTestRole]{ChildKey: "Id"}).pivotChildKey,
+			expected: []any{"2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffPivotIds(tt.childIds, existing, tt.key))
+		})
+	}
+}
+
+func TestManyToManyAttach(t *testing.T) {
+	// 3 lo agregó otra petición al mismo tiempo: ON CONFLICT no lo devuelve
+	pivot := &fakePivot{existing: []driver.Value{int64(1)}, returning: []driver.Value{int64(2)}}
+	loader, db := newPivotTestLoader(pivot)
+
+	changes, err := loader.Attach(context.Background(), int64(7), []any{"1", "2", 3, int64(3)}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []any{"2"}, changes.Attached)
+	assert.Empty(t, changes.Detached)
+
+	queries := db.recorded()
+	require.Len(t, queries, 2)
+	assert.Equal(t, "SELECT role_id FROM role_users WHERE user_id = $1", queries[0].query)
+	assert.Equal(t, []any{int64(7)}, queries[0].args)
+	assert.Equal(t, "INSERT INTO role_users (user_id, role_id) VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING RETURNING role_id", queries[1].query)
+	assert.Equal(t, []any{int64(7), "2", int64(7), 3}, queries[1].args)
+
+	assert.Equal(t, 1, db.begins)
+	assert.Equal(t, 1, db.commits)
+	assert.Equal(t, 0, db.rollbacks)
+}
+
+func TestManyToManyAttachWithTimestamps(t *testing.T) {
+	loader, db := newPivotTestLoader(&fakePivot{})
+	loader.PivoteTimestamps = true
+
+	changes, err := loader.Attach(context.Background(), int64(7), []any{int64(2)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(2)}, changes.Attached)
+
+	queries := db.recorded()
+	require.Len(t, queries, 2)
+	assert.Equal(t, "INSERT INTO role_users (user_id, role_id, created_at, updated_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING RETURNING role_id", queries[1].query)
+	require.Len(t, queries[1].args, 4)
+	assert.Equal(t, queries[1].args[2], queries[1].args[3])
+}
+
+func TestManyToManySync(t *testing.T) {
+	tests := []struct {
+		name             string
+		existing         []driver.Value
+		deleted          []driver.Value
+		childIds         []any
+		expectedQueries  []string
+		expectedAttached []any
+		expectedDetached []any
+	}{
+		{
+			name:     "solo la diferencia",
+			existing: []driver.Value{int64(1), int64(5)},
+			deleted:  []driver.Value{int64(1)},
+			childIds: []any{int64(5), int64(7)},
+			expectedQueries: []string{
+				"SELECT role_id FROM role_users WHERE user_id = $1",
+				"DELETE FROM role_users WHERE user_id = $1 AND role_id IN ($2) RETURNING role_id",
+				"INSERT INTO role_users (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING role_id",
+			},
+			expectedAttached: []any{int64(7)},
+			expectedDetached: []any{int64(1)},
+		},
+		{
+			name:     "ids en texto contra columna entera",
+			existing: []driver.Value{int64(1), int64(5)},
+			deleted:  []driver.Value{int64(1)},
+			childIds: []any{"5", "7"},
+			expectedQueries: []string{
+				"SELECT role_id FROM role_users WHERE user_id = $1",
+				"DELETE FROM role_users WHERE user_id = $1 AND role_id IN ($2) RETURNING role_id",
+				"INSERT INTO role_users (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING role_id",
+			},
+			expectedAttached: []any{"7"},
+			expectedDetached: []any{int64(1)},
+		},
+		{
+			name:             "sin cambios",
+			existing:         []driver.Value{int64(1)},
+			childIds:         []any{1},
+			expectedQueries:  []string{"SELECT role_id FROM role_users WHERE user_id = $1"},
+			expectedAttached: []any{},
+			expectedDetached: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, db := newPivotTestLoader(&fakePivot{existing: tt.existing, deleted: tt.deleted})
+
+			changes, err := loader.Sync(context.Background(), int64(9), tt.childIds, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedAttached, changes.Attached)
+			assert.Equal(t, tt.expectedDetached, changes.Detached)
+
+			queries := []string{}
+			for _, query := range db.recorded() {
+				queries = append(queries, query.query)
+			}
+			assert.Equal(t, tt.expectedQueries, queries)
+		})
+	}
+}
+
+func TestManyToManyDetach(t *testing.T) {
+	loader, db := newPivotTestLoader(&fakePivot{deleted: []driver.Value{int64(2), []byte("3")}})
+
+	changes, err := loader.Detach(context.Background(), int64(7), []any{2, 3, 4}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(2), "3"}, changes.Detached)
+	assert.Empty(t, changes.Attached)
+
+	queries := db.recorded()
+	require.Len(t, queries, 1)
+	assert.Equal(t, "DELETE FROM role_users WHERE user_id = $1 AND role_id IN ($2, $3, $4) RETURNING role_id", queries[0].query)
+	assert.Equal(t, []any{int64(7), 2, 3, 4}, queries[0].args)
+
+	// Sin hijos no se consulta ni se abre transacción
+	changes, err = loader.Detach(context.Background(), int64(7), nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, changes.Detached)
+	assert.Len(t, db.recorded(), 1)
+	assert.Equal(t, 1, db.begins)
+}
+
+func TestManyToManyDetachAll(t *testing.T) {
+	loader, db := newPivotTestLoader(&fakePivot{deleted: []driver.Value{int64(1), int64(2), int64(2)}})
+
+	changes, err := loader.DetachAll(context.Background(), int64(7), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(1), int64(2)}, changes.Detached)
+
+	queries := db.recorded()
+	require.Len(t, queries, 1)
+	assert.Equal(t, "DELETE FROM role_users WHERE user_id = $1 RETURNING role_id", queries[0].query)
+	assert.Equal(t, []any{int64(7)}, queries[0].args)
+}
+
+func TestManyToManyPivotRollsBackOnError(t *testing.T) {
+	loader, db := newPivotTestLoader(&fakePivot{insertErr: errors.New("unique violation")})
+
+	_, err := loader.Attach(context.Background(), int64(7), []any{2}, nil)
+	assert.ErrorContains(t, err, "unique violation")
+
+	assert.Equal(t, 1, db.begins)
+	assert.Equal(t, 0, db.commits)
+	assert.Equal(t, 1, db.rollbacks)
+}
+
+func TestManyToManyPivotUsesExternalTransaction(t *testing.T) {
+	loader, db := newPivotTestLoader(&fakePivot{})
+	ctx := context.Background()
+
+	sqlTx, err := loader.Connection.(*sql.DB).BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = loader.Attach(ctx, int64(7), []any{2}, &models.Transaction{Tx: sqlTx})
+	require.NoError(t, err)
+
+	// La transacción es del llamador: Attach no la confirma ni abre otra
+	assert.Equal(t, 1, db.begins)
+	assert.Equal(t, 0, db.commits)
+	require.NoError(t, sqlTx.Rollback())
+}
+
+func TestInsertPivotRowsSplitsBatches(t *testing.T) {
+	tests := []struct {
+		name            string
+		timestamps      bool
+		expectedBatches []int
+	}{
+		{name: "dos columnas", expectedBatches: []int{maxQueryParams / 2, 1}},
+		{name: "con timestamps", timestamps: true, expectedBatches: []int{maxQueryParams / 4, maxQueryParams / 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, db := newPivotTestLoader(&fakePivot{})
+			loader.PivoteTimestamps = tt.timestamps
+
+			childIds := make([]any, 0, maxQueryParams/2+1)
+			for i := 0; i < maxQueryParams/2+1; i++ {
+				childIds = append(childIds, int64(i))
+			}
+
+			attached, err := loader.insertPivotRows(context.Background(), loader.Connection.(sqlExecutor), int64(7), childIds)
+			require.NoError(t, err)
+			assert.Equal(t, childIds, attached)
+
+			columns := 2
+			if tt.timestamps {
+				columns = 4
+			}
+
+			batches := []int{}
+			for _, query := range db.recorded() {
+				assert.LessOrEqual(t, len(query.args), maxQueryParams)
+				batches = append(batches, len(query.args)/columns)
+			}
+			assert.Equal(t, tt.expectedBatches, batches)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package godbsql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+)
+
+// Profundidad máxima de una ruta de relaciones cuando la conexión no define MaxRelationDepth
+const DefaultMaxRelationDepth = 5
+
+// Segmento de relación a cargar con todas sus subrutas
+type relationNode struct {
+	name   string
+	childs []string
+}
+
+// Construye el árbol de relaciones pedidas: un nodo por primer segmento, en el orden en que aparecen,
+// con las subrutas sin repetir. Rechaza rutas vacías o más profundas que maxDepth
+func buildRelationTree(relations []string, maxDepth int) ([]*relationNode, error) {
+	nodes := []*relationNode{}
+	nodeForName := map[string]*relationNode{}
+	seenChilds := map[string]map[string]bool{}
+
+	for _, path := range relations {
+		segments := strings.Split(path, ".")
+		for _, segment := range segments {
+			if strings.TrimSpace(segment) == "" {
+				return nil, fmt.Errorf("invalid relation path: %q", path)
+			}
+		}
+
+		if maxDepth > 0 && len(segments) > maxDepth {
+			return nil, fmt.Errorf("relation path %s exceeds max depth of %d", path, maxDepth)
+		}
+
+		name := segments[0]
+		node, exists := nodeForName[name]
+		if !exists {
+			node = &relationNode{name: name, childs: []string{}}
+			nodeForName[name] = node
+			seenChilds[name] = map[string]bool{}
+			nodes = append(nodes, node)
+		}
+
+		if len(segments) == 1 {
+			continue
+		}
+
+		rest := strings.Join(segments[1:], ".")
+		if seenChilds[name][rest] {
+			continue
+		}
+
+		seenChilds[name][rest] = true
+		node.childs = append(node.childs, rest)
+	}
+
+	return nodes, nil
+}
+
+type relationChainKey struct{}
+
+// Loaders que ya se están ejecutando en la ruta actual, usado para medir la profundidad
+func relationChainFrom(ctx context.Context) []repository.RelationLoader {
+	chain, _ := ctx.Value(relationChainKey{}).([]repository.RelationLoader)
+	return chain
+}
+
+func withRelationChain(ctx context.Context, loader repository.RelationLoader) context.Context {
+	parent := relationChainFrom(ctx)
+	chain := make([]repository.RelationLoader, 0, len(parent)+1)
+	chain = append(chain, parent...)
+	chain = append(chain, loader)
+
+	return context.WithValue(ctx, relationChainKey{}, chain)
+}
+
+// Opciones de carga de una sola llamada a Get o LoadRelations. Se sacan del contexto al empezar la llamada
+// para que no alcancen a otras consultas hechas con el mismo contexto; cada loader recibe solo la parte
+// que corresponde a su relación
+type relationCall struct {
+	constraints map[string]RelationConstraint
+	aggregates  []RelationAggregate
+}
+
+func takeRelationCall(ctx context.Context) (context.Context, relationCall) {
+	call := relationCallFrom(ctx)
+	if call.isEmpty() {
+		return ctx, call
+	}
+
+	return withRelationCall(ctx, relationCall{}), call
+}
+
+// Contexto con las opciones de call, lo usan los loaders para pasar las rutas anidadas al Get de los hijos
+func withRelationCall(ctx context.Context, call relationCall) context.Context {
+	if call.isEmpty() && relationCallFrom(ctx).isEmpty() {
+		return ctx
+	}
+
+	ctx = context.WithValue(ctx, relationConstraintsKey{}, call.constraints)
+	return context.WithValue(ctx, relationAggregatesKey{}, call.aggregates)
+}
+
+func relationCallFrom(ctx context.Context) relationCall {
+	return relationCall{constraints: relationConstraintsFrom(ctx), aggregates: relationAggregatesFrom(ctx)}
+}
+
+func (r relationCall) isEmpty() bool {
+	return len(r.constraints) == 0 && len(r.aggregates) == 0
+}
+
+func (r relationCall) constraintFor(relation string) *RelationConstraint {
+	constraint, ok := r.constraints[relation]
+	if !ok {
+		return nil
+	}
+
+	return &constraint
+}
+
+// Opciones para los Get hijos de una relación: solo conserva las rutas debajo de ella, relativas a ella
+func (r relationCall) scope(relation string) relationCall {
+	prefix := relation + "."
+	nested := relationCall{}
+	for path, constraint := range r.constraints {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		if nested.constraints == nil {
+			nested.constraints = map[string]RelationConstraint{}
+		}
+		nested.constraints[strings.TrimPrefix(path, prefix)] = constraint
+	}
+
+	for _, aggregate := range r.aggregates {
+		if strings.HasPrefix(aggregate.Relation, prefix) {
+			aggregate.Relation = strings.TrimPrefix(aggregate.Relation, prefix)
+			nested.aggregates = append(nested.aggregates, aggregate)
+		}
+	}
+
+	return nested
+}
+
+// Agregados que corresponden al nivel actual, es decir, sin ruta anidada
+func (r relationCall) levelAggregates() []RelationAggregate {
+	result := []RelationAggregate{}
+	for _, aggregate := range r.aggregates {
+		if !strings.Contains(aggregate.Relation, ".") {
+			result = append(result, aggregate)
+		}
+	}
+
+	return result
+}
+
+// Un agregado con ruta ("roles.permissions") se calcula en el Get de los hijos de su primer segmento,
+// que por lo tanto tiene que estar entre las relaciones cargadas
+func (r relationCall) validateAggregatePaths(nodes []*relationNode) error {
+	loaded := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		loaded[node.name] = true
+	}
+
+	for _, aggregate := range r.aggregates {
+		name, _, isNested := strings.Cut(aggregate.Relation, ".")
+		if isNested && !loaded[name] {
+			return fmt.Errorf("aggregate on %s requires loading relation %s", aggregate.Relation, name)
+		}
+	}
+
+	return nil
+}
+
+// Loader que escribe sus hijos en un solo campo del padre
+type relationTargeter interface {
+	targetField() string
+}
+
+func (l *OnetoManyLoader[P, C]) targetField() string {
+	return l.ContainerField
+}
+
+func (m *ManyToManyLoader[P, C]) targetField() string {
+	return m.ContainerField
+}
+
+func (c *OnetoOneLoader[P, C]) targetField() string {
+	return c.ContainerField
+}
+
+func (h *HasManyThroughLoader[P, C]) targetField() string {
+	return h.ContainerField
+}
+
+func (m *MorphManyLoader[P, C]) targetField() string {
+	return m.ContainerField
+}
+
+func (m *MorphToLoader[P]) targetField() string {
+	return m.ContainerField
+}
+
+func (b *BelongsToLoader[P, C]) targetField() string {
+	return b.ContainerField
+}
+
+type relationTargetKey struct {
+	field string
+}
+
+// Llave con la que se agrupan las rutas que no pueden cargarse en paralelo: el campo que escribe el loader,
+// o el propio loader si es externo. Un loader externo no comparable no se agrupa
+func relationGroupKey(loader repository.RelationLoader) (any, bool) {
+	if targeter, ok := loader.(relationTargeter); ok {
+		return relationTargetKey{field: targeter.targetField()}, true
+	}
+
+	if reflect.TypeOf(loader).Comparable() {
+		return loader, true
+	}
+
+	return nil, false
+}
+
+// Cada agregado escribe su propio campo, distinto del de los demás agregados y del de las relaciones cargadas
+func validateRelationTargets(nodes []*relationNode, loaders map[string]repository.RelationLoader, aggregates []RelationAggregate) error {
+	writers := map[string]string{}
+	for _, node := range nodes {
+		if targeter, ok := loaders[node.name].(relationTargeter); ok {
+			writers[targeter.targetField()] = node.name
+		}
+	}
+
+	for _, aggregate := range aggregates {
+		if writer, exists := writers[aggregate.Field]; exists {
+			return fmt.Errorf("aggregate on %s writes field %s, already written by %s", aggregate.Relation, aggregate.Field, writer)
+		}
+
+		writers[aggregate.Field] = aggregate.Relation
+	}
+
+	return nil
+}
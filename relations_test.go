@@ -0,0 +1,137 @@
+package godbsql
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Loader de prueba que registra cuántas cargas se ejecutan a la vez sobre su campo
+type targetTestLoader struct {
+	field   string
+	running *int32
+	maxSeen *int32
+	mu      *sync.Mutex
+	order   *[]string
+	name    string
+}
+
+func (l *targetTestLoader) targetField() string {
+	return l.field
+}
+
+func (l *targetTestLoader) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	current := atomic.AddInt32(l.running, 1)
+	defer atomic.AddInt32(l.running, -1)
+
+	for {
+		seen := atomic.LoadInt32(l.maxSeen)
+		if current <= seen || atomic.CompareAndSwapInt32(l.maxSeen, seen, current) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	l.mu.Lock()
+	*l.order = append(*l.order, l.name)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func TestLoadRelationsSerializesLoadersWithSameTarget(t *testing.T) {
+	var running, maxSeen int32
+	var mu sync.Mutex
+	order := []string{}
+
+	newLoader := func(name string) *targetTestLoader {
+		return &targetTestLoader{field: "Roles", running: &running, maxSeen: &maxSeen, mu: &mu, order: &order, name: name}
+	}
+
+	conn := &Connection[*aggregateTestModel]{
+		Table:           "users",
+		RelationWorkers: 4,
+		RelationLoaders: map[string]repository.RelationLoader{
+			"roles":       newLoader("roles"),
+			"activeRoles": newLoader("activeRoles"),
+			"adminRoles":  newLoader("adminRoles"),
+		},
+	}
+
+	err := conn.LoadRelations(context.Background(), []*aggregateTestModel{{Id: "1"}}, []string{"roles", "activeRoles", "adminRoles"}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), maxSeen)
+	assert.Equal(t, []string{"roles", "activeRoles", "adminRoles"}, order)
+}
+
+func TestRelationGroupKey(t *testing.T) {
+	first := &OnetoManyLoader[*aggregateTestModel, *aggregateTestModel]{ContainerField: "Posts"}
+	second := &BelongsToLoader[*aggregateTestModel, *aggregateTestModel]{ContainerField: "Posts"}
+	other := &OnetoOneLoader[*aggregateTestModel, *aggregateTestModel]{ContainerField: "Profile"}
+
+	firstKey, ok := relationGroupKey(first)
+	require.True(t, ok)
+	secondKey, ok := relationGroupKey(second)
+	require.True(t, ok)
+	otherKey, ok := relationGroupKey(other)
+	require.True(t, ok)
+
+	assert.Equal(t, firstKey, secondKey)
+	assert.NotEqual(t, firstKey, otherKey)
+}
+
+func TestValidateRelationTargets(t *testing.T) {
+	loaders := map[string]repository.RelationLoader{
+		"posts": &OnetoManyLoader[*aggregateTestModel, *aggregateTestModel]{ContainerField: "Posts"},
+	}
+	nodes, err := buildRelationTree([]string{"posts"}, DefaultMaxRelationDepth)
+	require.NoError(t, err)
+
+	assert.NoError(t, validateRelationTargets(nodes, loaders, []RelationAggregate{
+		{Relation: "posts", Function: AggregateCount, Field: "PostsCount"},
+		{Relation: "comments", Function: AggregateCount, Field: "CommentsCount"},
+	}))
+
+	assert.Error(t, validateRelationTargets(nodes, loaders, []RelationAggregate{
+		{Relation: "posts", Function: AggregateCount, Field: "Posts"},
+	}))
+
+	assert.Error(t, validateRelationTargets(nodes, loaders, []RelationAggregate{
+		{Relation: "posts", Function: AggregateCount, Field: "Total"},
+		{Relation: "comments", Function: AggregateCount, Field: "Total"},
+	}))
+}
+
+func TestBuildRelationTree(t *testing.T) {
+	nodes, err := buildRelationTree([]string{"roles.permissions", "posts", "roles", "roles.permissions", "roles.users", "children.children"}, 3)
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+
+	assert.Equal(t, "roles", nodes[0].name)
+	assert.Equal(t, []string{"permissions", "users"}, nodes[0].childs)
+	assert.Equal(t, "posts", nodes[1].name)
+	assert.Empty(t, nodes[1].childs)
+	assert.Equal(t, "children", nodes[2].name)
+	assert.Equal(t, []string{"children"}, nodes[2].childs)
+
+	_, err = buildRelationTree([]string{"roles..permissions"}, 0)
+	assert.Error(t, err)
+
+	_, err = buildRelationTree([]string{"posts", " "}, 0)
+	assert.Error(t, err)
+
+	_, err = buildRelationTree([]string{"a.b.c.d"}, 3)
+	assert.ErrorContains(t, err, "exceeds max depth")
+
+	nodes, err = buildRelationTree([]string{"a.b.c.d"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.c.d"}, nodes[0].childs)
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Nemutagk/godb/v2"
@@ -16,7 +18,7 @@ import (
 	"github.com/Nemutagk/goenvars"
 	"github.com/Nemutagk/golog"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const (
@@ -27,22 +29,66 @@ const (
 	ComparatorGreaterThanOrEqual = ">="
 	ComparatorLessThanOrEqual    = "<="
 	ComparatorLike               = "LIKE"
+	ComparatorNotLike            = "NOT LIKE"
+	ComparatorILike              = "ILIKE"
+	ComparatorNotILike           = "NOT ILIKE"
+	ComparatorBetween            = "BETWEEN"
+	ComparatorNotBetween         = "NOT BETWEEN"
+	ComparatorRegex              = "~"
+	ComparatorRegexI             = "~*"
+	ComparatorNotRegex           = "!~"
+	ComparatorNotRegexI          = "!~*"
+	ComparatorIsDistinctFrom     = "IS DISTINCT FROM"
+	ComparatorIsNotDistinctFrom  = "IS NOT DISTINCT FROM"
+	ComparatorAny                = "ANY"
+	ComparatorAll                = "ALL"
+	ComparatorArrayContains      = "@>"
+	ComparatorArrayContainedBy   = "<@"
+	ComparatorArrayOverlaps      = "&&"
 	ComparatorIn                 = "IN"
 	ComparatorNotIn              = "NOT IN"
 	ComparatorIsNull             = "IS NULL"
 	ComparatorIsNotNull          = "IS NOT NULL"
+	ComparatorExists             = "EXISTS"
+	ComparatorNotExists          = "NOT EXISTS"
 	OperatorAnd                  = "AND"
 	OperatorOr                   = "OR"
 )
 
 var ErrorNoRows = sql.ErrNoRows
 
+// Error base de MissingRelationError, permite usar errors.Is
+var ErrRequiredRelation = errors.New("required relation not found")
+
+// Un loader con Required no encontró hijos para el padre con la llave ParentKey
+type MissingRelationError struct {
+	Field     string
+	ParentKey any
+}
+
+func (e *MissingRelationError) Error() string {
+	return fmt.Sprintf("required relation %s not found for parent key %v", e.Field, e.ParentKey)
+}
+
+func (e *MissingRelationError) Unwrap() error {
+	return ErrRequiredRelation
+}
+
+// Límite de parámetros por sentencia en Postgres
+const maxQueryParams = 65535
+
+// A partir de cuántos valores una lista IN / NOT IN se envía como un solo arreglo (= ANY($n))
+const InListArrayThreshold = 1000
+
 type RawSQL string
 
 func (r RawSQL) String() string {
 	return string(r)
 }
 
+// Referencia a otra columna como valor de un filtro (updated_at > created_at), se valida y se escribe sin parámetro
+type Column string
+
 type Model interface {
 	ScanFields() []any
 }
@@ -55,6 +101,8 @@ type NewConnectionConfig struct {
 	InsertTimestamps *bool
 	SoftDelete       *string
 	Relationer       map[string]repository.RelationLoader
+	RelationWorkers  int
+	MaxRelationDepth int
 }
 
 type OnetoManyLoader[P Model, C Model] struct {
@@ -62,6 +110,7 @@ type OnetoManyLoader[P Model, C Model] struct {
 	ParentField    string
 	ChildFkField   string
 	ContainerField string
+	Required       bool
 }
 
 type ManyToManyLoader[P Model, C Model] struct {
@@ -73,6 +122,13 @@ type ManyToManyLoader[P Model, C Model] struct {
 	PivoteChildKey  string
 	PivoteTable     string
 	ContainerField  string
+
+	PivoteColumns    []string
+	PivoteTimestamps bool
+	PivoteFilters    *models.GroupFilter
+	PivoteField      string
+
+	Required bool
 }
 
 type OnetoOneLoader[P Model, C Model] struct {
@@ -80,6 +136,77 @@ type OnetoOneLoader[P Model, C Model] struct {
 	ParentField    string
 	ChildFkField   string
 	ContainerField string
+	Required       bool
+}
+
+type HasManyThroughLoader[P Model, C Model] struct {
+	Repository       repository.DriverConnection[C]
+	Connection       any
+	ParentKey        string
+	ThroughTable     string
+	ThroughParentKey string
+	ThroughKey       string
+	ChildFkField     string
+	ContainerField   string
+	Required         bool
+}
+
+type MorphManyLoader[P Model, C Model] struct {
+	Repository     repository.DriverConnection[C]
+	ParentField    string
+	MorphIdField   string
+	MorphTypeField string
+	MorphType      string
+	ContainerField string
+	Required       bool
+}
+
+type MorphToLoader[P Model] struct {
+	MorphIdField   string
+	MorphTypeField string
+	Owners         map[string]MorphOwner
+	ContainerField string
+	Required       bool
+}
+
+// Repositorio dueño de una relación polimórfica, devuelve los modelos indexados por su llave
+type MorphOwner interface {
+	LoadOwners(ctx context.Context, ids []any, childs *[]string, loadOpts *RelationLoadOptions) (map[any]any, error)
+}
+
+type MorphOwnerRepository[C Model] struct {
+	Repository repository.DriverConnection[C]
+	OwnerKey   string
+}
+
+type BelongsToLoader[P Model, C Model] struct {
+	Repository     repository.DriverConnection[C]
+	ParentFkField  string
+	ChildKey       string
+	ContainerField string
+	Required       bool
+}
+
+// Opciones de la consulta padre que Connection.Get reenvía a cada loader
+type RelationLoadOptions struct {
+	Transaction *models.Transaction
+	Constraint  *RelationConstraint
+}
+
+// Loader que recibe las opciones de la consulta padre además de las relaciones hijas
+type RelationLoaderWithOptions interface {
+	repository.RelationLoader
+	LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error
+}
+
+// Repositorio capaz de limitar los resultados por cada valor de una columna en una sola consulta
+type PerParentGetter[T any] interface {
+	GetPerParent(ctx context.Context, filters models.GroupFilter, partitionColumn string, perParent int, opts *models.Options) ([]T, error)
+}
+
+// Repositorio que agrega su filtro de borrado lógico, usado al consultar su tabla directamente
+type softDeleteFilterer interface {
+	softDeleteFilters(filters models.GroupFilter) models.GroupFilter
 }
 
 type Connection[T Model] struct {
@@ -91,6 +218,8 @@ type Connection[T Model] struct {
 	RelationLoaders  map[string]repository.RelationLoader
 	InsertId         bool
 	InsertTimestamps bool
+	RelationWorkers  int
+	MaxRelationDepth int
 }
 
 // Interfaz para que sql.Row y sql.Rows puedan ser usados en la misma función
@@ -98,13 +227,101 @@ type Scannable interface {
 	Scan(dest ...any) error
 }
 
+// Interfaz común de *sql.DB y *sql.Tx para ejecutar consultas dentro o fuera de una transacción
+type sqlExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func resolveExecutor(conn any, tx *models.Transaction) (sqlExecutor, error) {
+	if tx != nil {
+		sqlTx, ok := tx.Tx.(*sql.Tx)
+		if !ok {
+			return nil, fmt.Errorf("failed to assert transaction to *sql.Tx")
+		}
+
+		return sqlTx, nil
+	}
+
+	sqlConn, ok := conn.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to assert connection to *sql.DB")
+	}
+
+	return sqlConn, nil
+}
+
+func loadRelation(ctx context.Context, loader repository.RelationLoader, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if withOptions, ok := loader.(RelationLoaderWithOptions); ok {
+		return withOptions.LoadWithOptions(ctx, parentModels, childs, loadOpts)
+	}
+
+	return loader.Load(ctx, parentModels, childs)
+}
+
+// Opciones para el Get de los hijos: relaciones anidadas, transacción de la consulta padre y restricciones de la relación
+func relationChildOptions(childs *[]string, loadOpts *RelationLoadOptions, keyColumn string) models.Options {
+	opts := models.Options{}
+
+	if childs != nil && len(*childs) > 0 {
+		opts.Relations = *childs
+	}
+
+	opts.Transaction = loadTransaction(loadOpts)
+
+	if loadOpts != nil && loadOpts.Constraint != nil {
+		loadOpts.Constraint.applyOptions(&opts, keyColumn)
+	}
+
+	return opts
+}
+
+// Agrega los filtros de la restricción de la relación a los filtros de llaves del loader
+func relationChildFilters(filters models.GroupFilter, loadOpts *RelationLoadOptions) models.GroupFilter {
+	if loadOpts == nil || loadOpts.Constraint == nil || loadOpts.Constraint.Filters == nil {
+		return filters
+	}
+
+	if len(loadOpts.Constraint.Filters.Filters) == 0 {
+		return filters
+	}
+
+	filters.Filters = append(append([]any{}, filters.Filters...), *loadOpts.Constraint.Filters)
+
+	return filters
+}
+
+func loadTransaction(loadOpts *RelationLoadOptions) *models.Transaction {
+	if loadOpts == nil {
+		return nil
+	}
+
+	return loadOpts.Transaction
+}
+
 func (l *OnetoManyLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return l.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (l *OnetoManyLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
 	if len(parentModels) == 0 {
 		return nil
 	}
 
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	// Sin orden los N hijos de cada padre serían cualquiera
+	perParent := perParentLimit(loadOpts)
+	if perParent > 0 && loadOpts.Constraint.OrderColumn == "" {
+		return fmt.Errorf("relation constraint PerParentLimit requires OrderColumn on one-to-many relations")
+	}
+
 	parentIds := make([]any, 0, len(parentModels))
-	for _, model := range parentModels {
+	parentIndexesForKey := map[any][]int{}
+	for parentIndex, model := range parentModels {
 		val := reflect.ValueOf(model)
 
 		for val.Kind() == reflect.Ptr {
@@ -117,6 +334,9 @@ func (l *OnetoManyLoader[P, C]) Load(ctx context.Context, parentModels []any, ch
 		}
 		parentId := parentIdField.Interface()
 		parentIds = append(parentIds, parentId)
+
+		key := normalizeKey(parentId)
+		parentIndexesForKey[key] = append(parentIndexesForKey[key], parentIndex)
 	}
 
 	in := ComparatorIn
@@ -130,94 +350,124 @@ func (l *OnetoManyLoader[P, C]) Load(ctx context.Context, parentModels []any, ch
 		},
 	}
 
-	opts := models.Options{}
+	opts := relationChildOptions(childs, loadOpts, l.ChildFkField)
 
-	if childs != nil && len(*childs) > 0 {
-		opts.Relations = *childs
+	var allChildrens []C
+	var err error
+	if getter, ok := l.Repository.(PerParentGetter[C]); ok && perParent > 0 {
+		allChildrens, err = getter.GetPerParent(ctx, relationChildFilters(filters, loadOpts), l.ChildFkField, perParent, &opts)
+	} else {
+		allChildrens, err = l.Repository.Get(ctx, relationChildFilters(filters, loadOpts), &opts)
 	}
-
-	allChildrens, err := l.Repository.Get(ctx, filters, &opts)
 	if err != nil {
 		return fmt.Errorf("failed to get child models: %w", err)
 	}
 
+	assignedForParent := map[int]int{}
+	foreignKeyTmp := prepareForeignKey(l.ChildFkField)
+
 	for _, child := range allChildrens {
 		valForFieldAcces := reflect.ValueOf(child)
 		for valForFieldAcces.Kind() == reflect.Ptr {
 			valForFieldAcces = valForFieldAcces.Elem()
 		}
 
-		foreignKeyTmp := prepareForeignKey(l.ChildFkField)
 		childFkValue := valForFieldAcces.FieldByName(foreignKeyTmp)
 		if !childFkValue.IsValid() {
 			return fmt.Errorf("invalid child foreign key field: %s, %s", foreignKeyTmp, l.ChildFkField)
 		}
 
-		foreignKey := childFkValue.Interface()
+		foreignKey := normalizeKey(childFkValue.Interface())
 
-		for _, parent := range parentModels {
-			parentVal := reflect.ValueOf(parent)
+		for _, parentIndex := range parentIndexesForKey[foreignKey] {
+			parentVal := reflect.ValueOf(parentModels[parentIndex])
 			for parentVal.Kind() == reflect.Ptr {
 				parentVal = parentVal.Elem()
 			}
 
-			parentIdField := parentVal.FieldByName(l.ParentField)
-			if !parentIdField.IsValid() {
-				return fmt.Errorf("invalid parent field: %s", l.ParentField)
-			}
-			parentId := parentIdField.Interface()
-
-			if foreignKey != parentId {
+			// Si el repositorio no soporta el límite por padre se recorta aquí
+			if perParent > 0 && assignedForParent[parentIndex] >= perParent {
 				continue
 			}
+			assignedForParent[parentIndex]++
 
 			containerField := parentVal.FieldByName(l.ContainerField)
 			if !containerField.IsValid() {
 				return fmt.Errorf("invalid container field: %s", l.ContainerField)
 			}
 
-			elemToAppend := valForFieldAcces
-			if containerField.Type().Elem().Kind() != reflect.Ptr && elemToAppend.Kind() == reflect.Ptr {
-				elemToAppend = elemToAppend.Elem()
-			} else if containerField.Type().Elem().Kind() == reflect.Ptr && elemToAppend.Kind() != reflect.Ptr {
-				ptr := reflect.New(elemToAppend.Type())
-				ptr.Elem().Set(elemToAppend)
-				elemToAppend = ptr
+			if err := appendToContainer(containerField, l.ContainerField, valForFieldAcces); err != nil {
+				return err
 			}
+		}
+	}
 
-			switch containerField.Kind() {
-			case reflect.Slice:
-				containerField.Set(reflect.Append(containerField, elemToAppend))
-			case reflect.Ptr:
-				if containerField.Type().Elem().Kind() != reflect.Slice {
-					return fmt.Errorf("container field pointer is not pointing to a slice: %s", l.ContainerField)
-				}
+	return requireRelation(l.Required, parentModels, l.ParentField, l.ContainerField)
+}
 
-				if containerField.IsNil() {
-					sliceType := containerField.Type().Elem()
-					emptySlce := reflect.MakeSlice(sliceType, 0, 0)
-					ptr := reflect.New(sliceType)
-					ptr.Elem().Set(emptySlce)
-					containerField.Set(ptr)
-				}
+// Agrega un hijo a un campo contenedor de tipo slice o puntero a slice
+func appendToContainer(containerField reflect.Value, fieldName string, childVal reflect.Value) error {
+	switch containerField.Kind() {
+	case reflect.Slice, reflect.Ptr:
+	default:
+		return fmt.Errorf("container field is not a slice or pointer to slice: %s", fieldName)
+	}
 
-				sliceVal := containerField.Elem()
-				sliceVal = reflect.Append(sliceVal, elemToAppend)
-				containerField.Elem().Set(sliceVal)
-			default:
-				return fmt.Errorf("container field is not a slice or pointer to slice: %s", l.ContainerField)
-			}
+	elemToAppend := childVal
+	if containerField.Type().Elem().Kind() != reflect.Ptr && elemToAppend.Kind() == reflect.Ptr {
+		elemToAppend = elemToAppend.Elem()
+	} else if containerField.Type().Elem().Kind() == reflect.Ptr && elemToAppend.Kind() != reflect.Ptr {
+		ptr := reflect.New(elemToAppend.Type())
+		ptr.Elem().Set(elemToAppend)
+		elemToAppend = ptr
+	}
+
+	switch containerField.Kind() {
+	case reflect.Slice:
+		containerField.Set(reflect.Append(containerField, elemToAppend))
+	case reflect.Ptr:
+		if containerField.Type().Elem().Kind() != reflect.Slice {
+			return fmt.Errorf("container field pointer is not pointing to a slice: %s", fieldName)
+		}
+
+		if containerField.IsNil() {
+			sliceType := containerField.Type().Elem()
+			emptySlce := reflect.MakeSlice(sliceType, 0, 0)
+			ptr := reflect.New(sliceType)
+			ptr.Elem().Set(emptySlce)
+			containerField.Set(ptr)
 		}
+
+		sliceVal := containerField.Elem()
+		sliceVal = reflect.Append(sliceVal, elemToAppend)
+		containerField.Elem().Set(sliceVal)
 	}
 
 	return nil
 }
 
 func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return m.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (m *ManyToManyLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
 	if len(parentModels) == 0 {
 		return nil
 	}
 
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := m.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	// Sin PivoteField las columnas pivote se leerían solo para descartarlas
+	if len(m.pivotColumns()) > 0 && m.PivoteField == "" {
+		return fmt.Errorf("PivoteColumns and PivoteTimestamps require PivoteField: %s", m.ContainerField)
+	}
+
 	parentModelsIds := []any{}
 	for _, model := range parentModels {
 		val := reflect.ValueOf(model)
@@ -238,33 +488,53 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 	queryBuilder.WriteString(m.PivoteParentKey)
 	queryBuilder.WriteString(", ")
 	queryBuilder.WriteString(m.PivoteChildKey)
+
+	pivotColumns := m.pivotColumns()
+	for _, col := range pivotColumns {
+		queryBuilder.WriteString(", ")
+		queryBuilder.WriteString(col)
+	}
+
 	queryBuilder.WriteString(" FROM ")
 	queryBuilder.WriteString(m.PivoteTable)
 	queryBuilder.WriteString(" WHERE ")
-	queryBuilder.WriteString(m.PivoteParentKey)
-	queryBuilder.WriteString(" IN (")
 
-	for i := range parentModelsIds {
-		if i > 0 {
-			queryBuilder.WriteString(", ")
-		}
+	inQuery, args, counter := inClause(m.PivoteParentKey, ComparatorIn, parentModelsIds, 1)
+	queryBuilder.WriteString(inQuery)
 
-		queryBuilder.WriteString(fmt.Sprintf("$%d", i+1))
+	if m.PivoteFilters != nil {
+		pivotFilters, pivotVals, _, err := prepareFilters(*m.PivoteFilters, counter, nil)
+		if err != nil {
+			return err
+		}
+		if pivotFilters != "" {
+			queryBuilder.WriteString(" AND (")
+			queryBuilder.WriteString(pivotFilters)
+			queryBuilder.WriteString(")")
+			args = append(args, pivotVals...)
+		}
 	}
-	queryBuilder.WriteString(")")
 
 	query := queryBuilder.String()
+	if perParent := perParentLimit(loadOpts); perParent > 0 {
+		rankedQuery, rankedArgs, err := m.rankPivotQuery(query, args, pivotColumns, perParent, loadOpts.Constraint)
+		if err != nil {
+			return err
+		}
+		query, args = rankedQuery, rankedArgs
+	}
+
 	if goenvars.GetEnvBool("SQL_DEBUG", false) {
 		golog.Log(ctx, "SQL Query:", query)
-		golog.Log(ctx, "SQL Values:", parentModelsIds)
+		golog.Log(ctx, "SQL Values:", args)
 	}
 
-	sqlConn, ok := m.Connection.(*sql.DB)
-	if !ok {
-		return fmt.Errorf("failed to assert connection to *sql.DB")
+	exec, err := resolveExecutor(m.Connection, loadTransaction(loadOpts))
+	if err != nil {
+		return err
 	}
 
-	rows, err := sqlConn.QueryContext(ctx, query, parentModelsIds...)
+	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to query pivot table: %w", err)
 	}
@@ -272,22 +542,45 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 
 	listChildForParent := map[any][]any{}
 	listAllChildIds := []any{}
+	pivotData := map[pivotRowKey]map[string]any{}
+	parentKeyType := modelFieldType[P](m.ParentKey)
+	childKeyType := modelFieldType[C](m.ChildKey)
 	for rows.Next() {
 		var parentId, childId any
 
-		if err := rows.Scan(&parentId, &childId); err != nil {
+		dest := []any{&parentId, &childId}
+		pivotValues := make([]any, len(pivotColumns))
+		for i := range pivotValues {
+			dest = append(dest, &pivotValues[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
 			return fmt.Errorf("failed to scan pivot row: %w", err)
 		}
 
-		if _, exists := listChildForParent[childId]; !exists {
-			listChildForParent[childId] = []any{}
+		childKey := normalizeKeyAs(childId, childKeyType)
+		parentKey := normalizeKeyAs(parentId, parentKeyType)
+		if _, exists := listChildForParent[childKey]; !exists {
+			listChildForParent[childKey] = []any{}
+			listAllChildIds = append(listAllChildIds, childKey)
 		}
 
-		listChildForParent[childId] = append(listChildForParent[childId], parentId)
-		listAllChildIds = append(listAllChildIds, childId)
+		listChildForParent[childKey] = append(listChildForParent[childKey], parentKey)
+
+		if len(pivotColumns) > 0 {
+			row := make(map[string]any, len(pivotColumns))
+			for i, col := range pivotColumns {
+				row[col] = pivotValues[i]
+			}
+			pivotData[pivotKey(parentKey, childKey)] = row
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
 	}
 
-	finalContainerChilds := map[string][]any{}
+	finalContainerChilds := map[any][]any{}
 	if len(listAllChildIds) > 0 {
 		in := ComparatorIn
 		filtersForChildren := models.GroupFilter{
@@ -300,13 +593,9 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 			},
 		}
 
-		opts := models.Options{}
-
-		if childs != nil && len(*childs) > 0 {
-			opts.Relations = *childs
-		}
+		opts := relationChildOptions(childs, loadOpts, m.ChildKey)
 
-		allChildren, err := m.Repository.Get(ctx, filtersForChildren, &opts)
+		allChildren, err := m.Repository.Get(ctx, relationChildFilters(filtersForChildren, loadOpts), &opts)
 		if err != nil {
 			return fmt.Errorf("failed to get child models: %w", err)
 		}
@@ -322,7 +611,7 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 				return fmt.Errorf("invalid child key field: %s", m.ChildKey)
 			}
 
-			childKeyValue := childKeyField.Interface()
+			childKeyValue := normalizeKey(childKeyField.Interface())
 
 			parentIdsForChild, exists := listChildForParent[childKeyValue]
 			if !exists {
@@ -331,21 +620,33 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 			}
 
 			for _, parentId := range parentIdsForChild {
-				_, exists := finalContainerChilds[fmt.Sprintf("%v", parentId)]
+				_, exists := finalContainerChilds[parentId]
 				if !exists {
-					finalContainerChilds[fmt.Sprintf("%v", parentId)] = []any{}
+					finalContainerChilds[parentId] = []any{}
 				}
 
 				elemToAppend := valForFieldAcces
-				if reflect.TypeOf(finalContainerChilds[fmt.Sprintf("%v", parentId)]).Elem().Kind() != reflect.Ptr && elemToAppend.Kind() == reflect.Ptr {
+				if m.PivoteField != "" {
+					// Cada padre recibe su propia copia del hijo con los datos de su fila pivote
+					copyVal := reflect.New(valForFieldAcces.Type()).Elem()
+					copyVal.Set(valForFieldAcces)
+
+					if err := setPivotField(copyVal, m.PivoteField, pivotData[pivotKey(parentId, childKeyValue)]); err != nil {
+						return err
+					}
+
+					elemToAppend = copyVal
+				}
+
+				if reflect.TypeOf(finalContainerChilds[parentId]).Elem().Kind() != reflect.Ptr && elemToAppend.Kind() == reflect.Ptr {
 					elemToAppend = elemToAppend.Elem()
-				} else if reflect.TypeOf(finalContainerChilds[fmt.Sprintf("%v", parentId)]).Elem().Kind() == reflect.Ptr && elemToAppend.Kind() != reflect.Ptr {
+				} else if reflect.TypeOf(finalContainerChilds[parentId]).Elem().Kind() == reflect.Ptr && elemToAppend.Kind() != reflect.Ptr {
 					ptr := reflect.New(elemToAppend.Type())
 					ptr.Elem().Set(elemToAppend)
 					elemToAppend = ptr
 				}
 
-				finalContainerChilds[fmt.Sprintf("%v", parentId)] = append(finalContainerChilds[fmt.Sprintf("%v", parentId)], elemToAppend.Interface())
+				finalContainerChilds[parentId] = append(finalContainerChilds[parentId], elemToAppend.Interface())
 			}
 		}
 	} else {
@@ -390,7 +691,7 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 		if !parentIdField.IsValid() {
 			return fmt.Errorf("invalid parent key field: %s", m.ParentKey)
 		}
-		parentKeyValue := fmt.Sprintf("%v", parentIdField.Interface())
+		parentKeyValue := normalizeKey(parentIdField.Interface())
 
 		// 2) Buscar hijos en el mapa
 		childs, ok := finalContainerChilds[parentKeyValue]
@@ -479,136 +780,912 @@ func (m *ManyToManyLoader[P, C]) Load(ctx context.Context, parentModels []any, c
 		}
 	}
 
-	return nil
+	return requireRelation(m.Required, parentModels, m.ParentKey, m.ContainerField)
 }
 
-func (c *OnetoOneLoader[P, C]) Load(ctx context.Context, parentModel []any, childs *[]string) error {
-	if parentModel == nil || len(parentModel) == 0 {
-		return nil
+// Envuelve la consulta pivote para quedarse con los primeros perParent hijos de cada padre,
+// ordenados por la columna de la restricción sobre la tabla de los hijos
+func (m *ManyToManyLoader[P, C]) rankPivotQuery(pivotQuery string, args []any, pivotColumns []string, perParent int, constraint *RelationConstraint) (string, []any, error) {
+	orderColumn := m.ChildKey
+	orderDir := "ASC"
+	if constraint.OrderColumn != "" {
+		if _, ok := m.Repository.GetOrderColumns()[constraint.OrderColumn]; !ok {
+			return "", nil, fmt.Errorf("invalid order column: %s", constraint.OrderColumn)
+		}
+		orderColumn = constraint.OrderColumn
 	}
 
-	val := reflect.ValueOf(parentModel[0])
-
-	for val.Kind() == reflect.Ptr {
-		val = val.Elem()
+	if strings.ToUpper(constraint.OrderDir) == "DESC" {
+		orderDir = "DESC"
 	}
 
-	parentIdField := val.FieldByName(c.ParentField)
-	if !parentIdField.IsValid() {
-		return fmt.Errorf("invalid parent field: %s", c.ParentField)
+	childTable := m.Repository.GetTableName()
+	if err := validateTableName(childTable); err != nil {
+		return "", nil, err
 	}
-	parentId := parentIdField.Interface()
 
-	filterChild := models.GroupFilter{
-		Filters: []any{
-			models.Filter{Key: c.ChildFkField, Value: parentId},
-		},
+	if err := validateIdentifier(orderColumn); err != nil {
+		return "", nil, err
 	}
 
-	opts := models.Options{}
+	childFilters := models.GroupFilter{Filters: []any{}}
+	if constraint.Filters != nil {
+		childFilters = *constraint.Filters
+	}
 
-	if childs != nil && len(*childs) > 0 {
-		opts.Relations = *childs
+	if softDelete, ok := m.Repository.(softDeleteFilterer); ok {
+		childFilters = softDelete.softDeleteFilters(childFilters)
 	}
 
-	childModel, err := c.Repository.GetOne(ctx, filterChild, &opts)
+	columns := append([]string{m.PivoteParentKey, m.PivoteChildKey}, pivotColumns...)
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT ")
+	queryBuilder.WriteString(strings.Join(columns, ", "))
+	queryBuilder.WriteString(" FROM (SELECT godbsql_pivot.*, ROW_NUMBER() OVER (PARTITION BY godbsql_pivot.")
+	queryBuilder.WriteString(m.PivoteParentKey)
+	queryBuilder.WriteString(" ORDER BY godbsql_child.")
+	queryBuilder.WriteString(orderColumn)
+	queryBuilder.WriteString(" ")
+	queryBuilder.WriteString(orderDir)
+	queryBuilder.WriteString(") AS godbsql_row_number FROM (")
+	queryBuilder.WriteString(pivotQuery)
+	queryBuilder.WriteString(") AS godbsql_pivot JOIN (SELECT * FROM ")
+	queryBuilder.WriteString(childTable)
+
+	childWhere, childVals, _, err := prepareFilters(childFilters, len(args)+1, repositoryFilterScope(m.Repository, childTable, 0))
 	if err != nil {
-		return fmt.Errorf("failed to load child model: %w", err)
+		return "", nil, err
+	}
+	if childWhere != "" {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(childWhere)
 	}
 
-	containerFlied := val.FieldByName(c.ContainerField)
-	if !containerFlied.IsValid() {
-		return fmt.Errorf("invalid container field: %s", c.ContainerField)
+	queryBuilder.WriteString(") AS godbsql_child ON godbsql_child.")
+	queryBuilder.WriteString(m.ChildKey)
+	queryBuilder.WriteString(" = godbsql_pivot.")
+	queryBuilder.WriteString(m.PivoteChildKey)
+	queryBuilder.WriteString(fmt.Sprintf(") AS godbsql_ranked WHERE godbsql_row_number <= %d", perParent))
+
+	return queryBuilder.String(), append(args, childVals...), nil
+}
+
+func (m *ManyToManyLoader[P, C]) pivotColumns() []string {
+	columns := append([]string{}, m.PivoteColumns...)
+	if m.PivoteTimestamps {
+		columns = append(columns, "created_at", "updated_at")
 	}
 
-	if !containerFlied.CanSet() {
-		return fmt.Errorf("cannot set container field: %s", c.ContainerField)
+	return columns
+}
+
+type pivotRowKey struct {
+	parentId any
+	childId  any
+}
+
+func pivotKey(parentId, childId any) pivotRowKey {
+	return pivotRowKey{parentId: normalizeKey(parentId), childId: normalizeKey(childId)}
+}
+
+// Escribe las columnas pivote en el campo indicado del hijo, acepta map[string]any, struct o puntero a struct
+func setPivotField(childVal reflect.Value, fieldName string, data map[string]any) error {
+	pivotField := childVal.FieldByName(fieldName)
+	if !pivotField.IsValid() {
+		return fmt.Errorf("invalid pivot field: %s", fieldName)
 	}
 
-	childVal := reflect.ValueOf(childModel)
+	if !pivotField.CanSet() {
+		return fmt.Errorf("cannot set pivot field: %s", fieldName)
+	}
 
-	switch containerFlied.Kind() {
-	case reflect.Ptr:
-		if childVal.Kind() != reflect.Ptr {
-			if childVal.Type() != containerFlied.Type().Elem() {
-				return fmt.Errorf("child type %s does not match container field type %s",
-					childVal.Type(), containerFlied.Type().Elem())
-			}
-			ptr := reflect.New(childVal.Type())
-			ptr.Elem().Set(childVal)
-			childVal = ptr
-		} else {
-			if childVal.Type() != containerFlied.Type() {
-				return fmt.Errorf("child pointer type %s does not match container field type %s",
-					childVal.Type(), containerFlied.Type())
+	if data == nil {
+		return nil
+	}
+
+	switch pivotField.Kind() {
+	case reflect.Map:
+		if pivotField.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("pivot field map must have string keys: %s", fieldName)
+		}
+
+		newMap := reflect.MakeMapWithSize(pivotField.Type(), len(data))
+		for col, value := range data {
+			elem := reflect.New(pivotField.Type().Elem()).Elem()
+			if err := setFieldValue(elem, value); err != nil {
+				return fmt.Errorf("invalid pivot value for %s: %w", col, err)
 			}
+			newMap.SetMapIndex(reflect.ValueOf(col).Convert(pivotField.Type().Key()), elem)
 		}
-		containerFlied.Set(childVal)
-	case reflect.Interface:
-		if childVal.Type().Implements(containerFlied.Type()) {
-			containerFlied.Set(childVal)
-		} else {
-			return fmt.Errorf("child type %s does not implement container interface type %s",
-				childVal.Type(), containerFlied.Type())
+		pivotField.Set(newMap)
+	case reflect.Ptr:
+		if pivotField.Type().Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("pivot field pointer is not pointing to a struct: %s", fieldName)
 		}
-	default:
-		if childVal.Kind() == reflect.Ptr {
-			childVal = childVal.Elem()
+
+		ptr := reflect.New(pivotField.Type().Elem())
+		if err := setStructColumns(ptr.Elem(), data); err != nil {
+			return err
 		}
-		if childVal.Type() != containerFlied.Type() {
-			return fmt.Errorf("child value type %s does not match container field type %s",
-				childVal.Type(), containerFlied.Type())
+		pivotField.Set(ptr)
+	case reflect.Struct:
+		newStruct := reflect.New(pivotField.Type()).Elem()
+		if err := setStructColumns(newStruct, data); err != nil {
+			return err
 		}
-		containerFlied.Set(childVal)
+		pivotField.Set(newStruct)
+	default:
+		return fmt.Errorf("pivot field is not a map, struct or pointer to struct: %s", fieldName)
 	}
 
 	return nil
 }
 
-func scanRow[T Model](row Scannable, model *T, returnColumns *[]string) error {
-	originalFields := (*model).ScanFields()
-	validateFields := []any{}
+func setStructColumns(structVal reflect.Value, data map[string]any) error {
+	for col, value := range data {
+		field := structVal.FieldByName(prepareForeignKey(col))
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
 
-	if returnColumns != nil {
-		modelVal := reflect.ValueOf(model).Elem()
-		for modelVal.Kind() == reflect.Ptr {
-			modelVal = modelVal.Elem()
+		if err := setFieldValue(field, value); err != nil {
+			return fmt.Errorf("invalid value for column %s: %w", col, err)
 		}
+	}
 
-		for _, col := range *returnColumns {
-			// convertir columna (snake_case) a nombre de campo CamelCase (ej. created_at -> CreatedAt)
-			fieldName := prepareForeignKey(col)
+	return nil
+}
 
-			// si no es struct válido, usamos placeholder
-			if modelVal.Kind() != reflect.Struct {
-				tmp := new(interface{})
-				validateFields = append(validateFields, tmp)
-				continue
-			}
+// Asigna un valor escaneado de la base de datos a un campo, convirtiendo tipos compatibles
+func setFieldValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
 
-			f := modelVal.FieldByName(fieldName)
-			if !f.IsValid() {
-				// campo no encontrado: usar placeholder para que Scan no falle
-				tmp := new(interface{})
-				validateFields = append(validateFields, tmp)
-				continue
-			}
+	if bytesValue, ok := value.([]byte); ok && field.Kind() != reflect.Slice {
+		value = string(bytesValue)
+	}
 
-			// pasar puntero direccionable al campo
-			if f.CanAddr() {
-				validateFields = append(validateFields, f.Addr().Interface())
-			} else {
-				// campo no direccionable -> crear placeholder del mismo tipo
-				tmp := reflect.New(f.Type()).Interface()
-				validateFields = append(validateFields, tmp)
-			}
+	val := reflect.ValueOf(value)
+
+	if field.Kind() == reflect.Interface {
+		if !val.Type().Implements(field.Type()) {
+			return fmt.Errorf("value type %s does not implement %s", val.Type(), field.Type())
 		}
-	} else {
-		validateFields = originalFields
+		field.Set(val)
+		return nil
 	}
 
-	tempFields := make([]any, len(validateFields))
-	nullableTimeIndices := make(map[int]*sql.NullTime)
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if val.Type().AssignableTo(field.Type()) {
+		field.Set(val)
+		return nil
+	}
+
+	if isNumericKind(val.Kind()) && isNumericKind(field.Kind()) || val.Kind() == reflect.String && field.Kind() == reflect.String {
+		field.Set(val.Convert(field.Type()))
+		return nil
+	}
+
+	// Postgres devuelve numeric como texto
+	if val.Kind() == reflect.String && isNumericKind(field.Kind()) {
+		number, err := strconv.ParseFloat(val.String(), 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as number: %w", val.String(), err)
+		}
+		field.Set(reflect.ValueOf(number).Convert(field.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("cannot assign %s to %s", val.Type(), field.Type())
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+func (h *HasManyThroughLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return h.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (h *HasManyThroughLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if len(parentModels) == 0 {
+		return nil
+	}
+
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := unsupportedPerParentLimit(loadOpts); err != nil {
+		return err
+	}
+
+	if err := h.validateIdentifiers(); err != nil {
+		return err
+	}
+
+	parentModelsIds := []any{}
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		parentIdField := val.FieldByName(h.ParentKey)
+		if !parentIdField.IsValid() {
+			return fmt.Errorf("invalid parent key field: %s", h.ParentKey)
+		}
+
+		parentModelsIds = append(parentModelsIds, parentIdField.Interface())
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT ")
+	queryBuilder.WriteString(h.ThroughParentKey)
+	queryBuilder.WriteString(", ")
+	queryBuilder.WriteString(h.ThroughKey)
+	queryBuilder.WriteString(" FROM ")
+	queryBuilder.WriteString(h.ThroughTable)
+	queryBuilder.WriteString(" WHERE ")
+
+	inQuery, args, _ := inClause(h.ThroughParentKey, ComparatorIn, parentModelsIds, 1)
+	queryBuilder.WriteString(inQuery)
+
+	query := queryBuilder.String()
+	if goenvars.GetEnvBool("SQL_DEBUG", false) {
+		golog.Log(ctx, "SQL Query:", query)
+		golog.Log(ctx, "SQL Values:", args)
+	}
+
+	exec, err := resolveExecutor(h.Connection, loadTransaction(loadOpts))
+	if err != nil {
+		return err
+	}
+
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query through table: %w", err)
+	}
+	defer rows.Close()
+
+	// Relación llave intermedia -> padres que la alcanzan
+	parentsForThrough := map[any][]any{}
+	listThroughIds := []any{}
+	parentKeyType := modelFieldType[P](h.ParentKey)
+	throughKeyType := modelFieldType[C](prepareForeignKey(h.ChildFkField))
+	for rows.Next() {
+		var parentId, throughId any
+
+		if err := rows.Scan(&parentId, &throughId); err != nil {
+			return fmt.Errorf("failed to scan through row: %w", err)
+		}
+
+		throughKey := normalizeKeyAs(throughId, throughKeyType)
+		if _, exists := parentsForThrough[throughKey]; !exists {
+			listThroughIds = append(listThroughIds, throughKey)
+		}
+
+		parentsForThrough[throughKey] = append(parentsForThrough[throughKey], normalizeKeyAs(parentId, parentKeyType))
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(listThroughIds) == 0 {
+		return requireRelation(h.Required, parentModels, h.ParentKey, h.ContainerField)
+	}
+
+	in := ComparatorIn
+	filters := models.GroupFilter{
+		Filters: []any{
+			models.FilterMultipleValue{
+				Key:        h.ChildFkField,
+				Values:     listThroughIds,
+				Comparator: &in,
+			},
+		},
+	}
+
+	opts := relationChildOptions(childs, loadOpts, h.ChildFkField)
+
+	allChildren, err := h.Repository.Get(ctx, relationChildFilters(filters, loadOpts), &opts)
+	if err != nil {
+		return fmt.Errorf("failed to get child models: %w", err)
+	}
+
+	// Agrupar los hijos por padre sin repetir el mismo hijo
+	foreignKeyTmp := prepareForeignKey(h.ChildFkField)
+	childrenForParent := map[any][]reflect.Value{}
+	seenForParent := map[any]map[int]bool{}
+	for i, child := range allChildren {
+		childVal := reflect.ValueOf(child)
+		for childVal.Kind() == reflect.Ptr {
+			childVal = childVal.Elem()
+		}
+
+		childFkValue := childVal.FieldByName(foreignKeyTmp)
+		if !childFkValue.IsValid() {
+			return fmt.Errorf("invalid child foreign key field: %s, %s", foreignKeyTmp, h.ChildFkField)
+		}
+
+		for _, parentId := range parentsForThrough[normalizeKey(childFkValue.Interface())] {
+			if seenForParent[parentId] == nil {
+				seenForParent[parentId] = map[int]bool{}
+			}
+
+			if seenForParent[parentId][i] {
+				continue
+			}
+
+			seenForParent[parentId][i] = true
+			childrenForParent[parentId] = append(childrenForParent[parentId], childVal)
+		}
+	}
+
+	for _, parent := range parentModels {
+		parentVal := reflect.ValueOf(parent)
+		for parentVal.Kind() == reflect.Ptr {
+			parentVal = parentVal.Elem()
+		}
+
+		parentId := normalizeKey(parentVal.FieldByName(h.ParentKey).Interface())
+		children, ok := childrenForParent[parentId]
+		if !ok {
+			continue
+		}
+
+		containerField := parentVal.FieldByName(h.ContainerField)
+		if !containerField.IsValid() {
+			return fmt.Errorf("invalid container field: %s", h.ContainerField)
+		}
+
+		for _, childVal := range children {
+			if err := appendToContainer(containerField, h.ContainerField, childVal); err != nil {
+				return err
+			}
+		}
+	}
+
+	return requireRelation(h.Required, parentModels, h.ParentKey, h.ContainerField)
+}
+
+func (c *OnetoOneLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return c.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (c *OnetoOneLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if len(parentModels) == 0 {
+		return nil
+	}
+
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := unsupportedPerParentLimit(loadOpts); err != nil {
+		return err
+	}
+
+	parentIds := make([]any, 0, len(parentModels))
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		parentIdField := val.FieldByName(c.ParentField)
+		if !parentIdField.IsValid() {
+			return fmt.Errorf("invalid parent field: %s", c.ParentField)
+		}
+		parentIds = append(parentIds, parentIdField.Interface())
+	}
+
+	// Cada hijo indexado por su llave foránea, el primero encontrado gana
+	childForParent, err := loadByKey(ctx, c.Repository, c.ChildFkField, parentIds, childs, loadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to load child model: %w", err)
+	}
+
+	for _, parent := range parentModels {
+		val := reflect.ValueOf(parent)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		parentId := normalizeKey(val.FieldByName(c.ParentField).Interface())
+		childModel, ok := childForParent[parentId]
+		if !ok {
+			continue // este padre no tiene hijo, el contenedor queda en nil
+		}
+
+		containerField := val.FieldByName(c.ContainerField)
+		if !containerField.IsValid() {
+			return fmt.Errorf("invalid container field: %s", c.ContainerField)
+		}
+
+		if err := setSingleContainer(containerField, c.ContainerField, childModel); err != nil {
+			return err
+		}
+	}
+
+	return requireRelation(c.Required, parentModels, c.ParentField, c.ContainerField)
+}
+
+func (b *BelongsToLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return b.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (b *BelongsToLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if len(parentModels) == 0 {
+		return nil
+	}
+
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := unsupportedPerParentLimit(loadOpts); err != nil {
+		return err
+	}
+
+	foreignKeys := make([]any, 0, len(parentModels))
+	seen := map[any]bool{}
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		fkField := val.FieldByName(b.ParentFkField)
+		if !fkField.IsValid() {
+			return fmt.Errorf("invalid parent foreign key field: %s", b.ParentFkField)
+		}
+
+		fk, ok := indirectValue(fkField)
+		if !ok {
+			continue // llave foránea nula, no hay dueño que cargar
+		}
+
+		key := normalizeKey(fk)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	if len(foreignKeys) == 0 {
+		return requireRelation(b.Required, parentModels, b.ParentFkField, b.ContainerField)
+	}
+
+	ownerForKey, err := loadByKey(ctx, b.Repository, b.ChildKey, foreignKeys, childs, loadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to get owner models: %w", err)
+	}
+
+	for _, parent := range parentModels {
+		val := reflect.ValueOf(parent)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		fk, ok := indirectValue(val.FieldByName(b.ParentFkField))
+		if !ok {
+			continue
+		}
+
+		owner, ok := ownerForKey[normalizeKey(fk)]
+		if !ok {
+			continue
+		}
+
+		containerField := val.FieldByName(b.ContainerField)
+		if !containerField.IsValid() {
+			return fmt.Errorf("invalid container field: %s", b.ContainerField)
+		}
+
+		if err := setSingleContainer(containerField, b.ContainerField, owner); err != nil {
+			return err
+		}
+	}
+
+	return requireRelation(b.Required, parentModels, b.ParentFkField, b.ContainerField)
+}
+
+func (m *MorphManyLoader[P, C]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return m.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (m *MorphManyLoader[P, C]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if len(parentModels) == 0 {
+		return nil
+	}
+
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := unsupportedPerParentLimit(loadOpts); err != nil {
+		return err
+	}
+
+	parentIds := make([]any, 0, len(parentModels))
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		parentIdField := val.FieldByName(m.ParentField)
+		if !parentIdField.IsValid() {
+			return fmt.Errorf("invalid parent field: %s", m.ParentField)
+		}
+		parentIds = append(parentIds, parentIdField.Interface())
+	}
+
+	in := ComparatorIn
+	filters := models.GroupFilter{
+		Filters: []any{
+			models.Filter{
+				Key:   m.MorphTypeField,
+				Value: m.MorphType,
+			},
+			models.FilterMultipleValue{
+				Key:        m.MorphIdField,
+				Values:     parentIds,
+				Comparator: &in,
+			},
+		},
+	}
+
+	opts := relationChildOptions(childs, loadOpts, m.MorphIdField)
+
+	allChildren, err := m.Repository.Get(ctx, relationChildFilters(filters, loadOpts), &opts)
+	if err != nil {
+		return fmt.Errorf("failed to get child models: %w", err)
+	}
+
+	morphIdField := prepareForeignKey(m.MorphIdField)
+	childrenForParent := map[any][]reflect.Value{}
+	for _, child := range allChildren {
+		childVal := reflect.ValueOf(child)
+		for childVal.Kind() == reflect.Ptr {
+			childVal = childVal.Elem()
+		}
+
+		childIdValue := childVal.FieldByName(morphIdField)
+		if !childIdValue.IsValid() {
+			return fmt.Errorf("invalid child morph id field: %s, %s", morphIdField, m.MorphIdField)
+		}
+
+		key := normalizeKey(childIdValue.Interface())
+		childrenForParent[key] = append(childrenForParent[key], childVal)
+	}
+
+	for _, parent := range parentModels {
+		parentVal := reflect.ValueOf(parent)
+		for parentVal.Kind() == reflect.Ptr {
+			parentVal = parentVal.Elem()
+		}
+
+		children, ok := childrenForParent[normalizeKey(parentVal.FieldByName(m.ParentField).Interface())]
+		if !ok {
+			continue
+		}
+
+		containerField := parentVal.FieldByName(m.ContainerField)
+		if !containerField.IsValid() {
+			return fmt.Errorf("invalid container field: %s", m.ContainerField)
+		}
+
+		for _, childVal := range children {
+			if err := appendToContainer(containerField, m.ContainerField, childVal); err != nil {
+				return err
+			}
+		}
+	}
+
+	return requireRelation(m.Required, parentModels, m.ParentField, m.ContainerField)
+}
+
+func (m *MorphToLoader[P]) Load(ctx context.Context, parentModels []any, childs *[]string) error {
+	return m.LoadWithOptions(ctx, parentModels, childs, nil)
+}
+
+func (m *MorphToLoader[P]) LoadWithOptions(ctx context.Context, parentModels []any, childs *[]string, loadOpts *RelationLoadOptions) error {
+	if len(parentModels) == 0 {
+		return nil
+	}
+
+	if err := validateRelationConstraint(loadOpts); err != nil {
+		return err
+	}
+
+	if err := unsupportedPerParentLimit(loadOpts); err != nil {
+		return err
+	}
+
+	// Agrupar las llaves por tipo para hacer una sola consulta por repositorio
+	idsForType := map[string][]any{}
+	seenForType := map[string]map[any]bool{}
+	for _, model := range parentModels {
+		val := reflect.ValueOf(model)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		typeField := val.FieldByName(m.MorphTypeField)
+		if !typeField.IsValid() {
+			return fmt.Errorf("invalid morph type field: %s", m.MorphTypeField)
+		}
+
+		idField := val.FieldByName(m.MorphIdField)
+		if !idField.IsValid() {
+			return fmt.Errorf("invalid morph id field: %s", m.MorphIdField)
+		}
+
+		morphType, ok := indirectValue(typeField)
+		if !ok {
+			continue
+		}
+
+		morphId, ok := indirectValue(idField)
+		if !ok {
+			continue
+		}
+
+		typeKey := fmt.Sprintf("%v", morphType)
+		idKey := normalizeKey(morphId)
+		if seenForType[typeKey] == nil {
+			seenForType[typeKey] = map[any]bool{}
+		}
+
+		if seenForType[typeKey][idKey] {
+			continue
+		}
+
+		seenForType[typeKey][idKey] = true
+		idsForType[typeKey] = append(idsForType[typeKey], morphId)
+	}
+
+	ownersForType := map[string]map[any]any{}
+	for typeKey, ids := range idsForType {
+		owner, ok := m.Owners[typeKey]
+		if !ok {
+			return fmt.Errorf("morph owner not registered for type: %s", typeKey)
+		}
+
+		owners, err := owner.LoadOwners(ctx, ids, childs, loadOpts)
+		if err != nil {
+			return fmt.Errorf("failed to load morph owners for type %s: %w", typeKey, err)
+		}
+
+		ownersForType[typeKey] = owners
+	}
+
+	for _, parent := range parentModels {
+		val := reflect.ValueOf(parent)
+		for val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		morphType, ok := indirectValue(val.FieldByName(m.MorphTypeField))
+		if !ok {
+			continue
+		}
+
+		morphId, ok := indirectValue(val.FieldByName(m.MorphIdField))
+		if !ok {
+			continue
+		}
+
+		owner, ok := ownersForType[fmt.Sprintf("%v", morphType)][normalizeKey(morphId)]
+		if !ok {
+			continue
+		}
+
+		containerField := val.FieldByName(m.ContainerField)
+		if !containerField.IsValid() {
+			return fmt.Errorf("invalid container field: %s", m.ContainerField)
+		}
+
+		if err := setSingleContainer(containerField, m.ContainerField, owner); err != nil {
+			return err
+		}
+	}
+
+	return requireRelation(m.Required, parentModels, m.MorphIdField, m.ContainerField)
+}
+
+func (o *MorphOwnerRepository[C]) LoadOwners(ctx context.Context, ids []any, childs *[]string, loadOpts *RelationLoadOptions) (map[any]any, error) {
+	owners, err := loadByKey(ctx, o.Repository, o.OwnerKey, ids, childs, loadOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	ownersForKey := make(map[any]any, len(owners))
+	for key, owner := range owners {
+		ownersForKey[key] = owner
+	}
+
+	return ownersForKey, nil
+}
+
+// Desenvuelve punteros, interfaces y driver.Valuer (sql.NullInt64, uuid.NullUUID...) de un campo,
+// devuelve false si el valor es nulo
+func indirectValue(field reflect.Value) (any, bool) {
+	for field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return nil, false
+		}
+		field = field.Elem()
+	}
+
+	if driverValue, ok := valuerValue(field); ok {
+		return driverValue, driverValue != nil
+	}
+
+	return field.Interface(), true
+}
+
+// Si la relación es requerida verifica que cada padre tenga su contenedor con al menos un hijo.
+// Las relaciones opcionales dejan el contenedor nil o vacío
+func requireRelation(required bool, parentModels []any, keyField, containerField string) error {
+	if !required {
+		return nil
+	}
+
+	for _, parent := range parentModels {
+		parentVal := reflect.ValueOf(parent)
+		for parentVal.Kind() == reflect.Ptr {
+			parentVal = parentVal.Elem()
+		}
+
+		container := parentVal.FieldByName(containerField)
+		if !container.IsValid() {
+			return fmt.Errorf("invalid container field: %s", containerField)
+		}
+
+		if !isEmptyContainer(container) {
+			continue
+		}
+
+		var parentKey any
+		if keyValue := parentVal.FieldByName(keyField); keyValue.IsValid() {
+			parentKey, _ = indirectValue(keyValue)
+		}
+
+		return &MissingRelationError{Field: containerField, ParentKey: parentKey}
+	}
+
+	return nil
+}
+
+func isEmptyContainer(container reflect.Value) bool {
+	for container.Kind() == reflect.Ptr || container.Kind() == reflect.Interface {
+		if container.IsNil() {
+			return true
+		}
+		container = container.Elem()
+	}
+
+	switch container.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return container.Len() == 0
+	}
+
+	return container.IsZero()
+}
+
+// Asigna un único hijo a un campo contenedor de tipo puntero, interfaz o struct
+func setSingleContainer(containerField reflect.Value, fieldName string, childModel any) error {
+	if !containerField.CanSet() {
+		return fmt.Errorf("cannot set container field: %s", fieldName)
+	}
+
+	childVal := reflect.ValueOf(childModel)
+
+	switch containerField.Kind() {
+	case reflect.Ptr:
+		if childVal.Kind() != reflect.Ptr {
+			if childVal.Type() != containerField.Type().Elem() {
+				return fmt.Errorf("child type %s does not match container field type %s",
+					childVal.Type(), containerField.Type().Elem())
+			}
+			ptr := reflect.New(childVal.Type())
+			ptr.Elem().Set(childVal)
+			childVal = ptr
+		} else {
+			if childVal.Type() != containerField.Type() {
+				return fmt.Errorf("child pointer type %s does not match container field type %s",
+					childVal.Type(), containerField.Type())
+			}
+		}
+		containerField.Set(childVal)
+	case reflect.Interface:
+		if childVal.Type().Implements(containerField.Type()) {
+			containerField.Set(childVal)
+		} else {
+			return fmt.Errorf("child type %s does not implement container interface type %s",
+				childVal.Type(), containerField.Type())
+		}
+	default:
+		if childVal.Kind() == reflect.Ptr {
+			childVal = childVal.Elem()
+		}
+		if childVal.Type() != containerField.Type() {
+			return fmt.Errorf("child value type %s does not match container field type %s",
+				childVal.Type(), containerField.Type())
+		}
+		containerField.Set(childVal)
+	}
+
+	return nil
+}
+
+func scanRow[T Model](row Scannable, model *T, returnColumns *[]string) error {
+	originalFields := (*model).ScanFields()
+	validateFields := []any{}
+
+	if returnColumns != nil {
+		modelVal := reflect.ValueOf(model).Elem()
+		for modelVal.Kind() == reflect.Ptr {
+			modelVal = modelVal.Elem()
+		}
+
+		for _, col := range *returnColumns {
+			// convertir columna (snake_case) a nombre de campo CamelCase (ej. created_at -> CreatedAt)
+			fieldName := prepareForeignKey(col)
+
+			// si no es struct válido, usamos placeholder
+			if modelVal.Kind() != reflect.Struct {
+				tmp := new(interface{})
+				validateFields = append(validateFields, tmp)
+				continue
+			}
+
+			f := modelVal.FieldByName(fieldName)
+			if !f.IsValid() {
+				// campo no encontrado: usar placeholder para que Scan no falle
+				tmp := new(interface{})
+				validateFields = append(validateFields, tmp)
+				continue
+			}
+
+			// pasar puntero direccionable al campo
+			if f.CanAddr() {
+				validateFields = append(validateFields, f.Addr().Interface())
+			} else {
+				// campo no direccionable -> crear placeholder del mismo tipo
+				tmp := reflect.New(f.Type()).Interface()
+				validateFields = append(validateFields, tmp)
+			}
+		}
+	} else {
+		validateFields = originalFields
+	}
+
+	tempFields := make([]any, len(validateFields))
+	nullableTimeIndices := make(map[int]*sql.NullTime)
 
 	for i, field := range validateFields {
 		// Si el campo es un puntero a time.Time, usamos un sustituto
@@ -640,6 +1717,20 @@ func scanRow[T Model](row Scannable, model *T, returnColumns *[]string) error {
 }
 
 func NewConnection[T Model](config NewConnectionConfig) (repository.DriverConnection[T], error) {
+	if err := validateTableName(config.Table); err != nil {
+		return nil, err
+	}
+
+	if err := validateColumns(config.OrderColumns); err != nil {
+		return nil, err
+	}
+
+	if config.SoftDelete != nil && *config.SoftDelete != "" {
+		if err := validateIdentifier(*config.SoftDelete); err != nil {
+			return nil, err
+		}
+	}
+
 	db, err := godb.GetConnection(config.Name)
 	if err != nil {
 		return nil, err
@@ -673,9 +1764,15 @@ func NewConnection[T Model](config NewConnectionConfig) (repository.DriverConnec
 		RelationLoaders:  config.Relationer,
 		InsertId:         *config.InsertId,
 		InsertTimestamps: *config.InsertTimestamps,
+		RelationWorkers:  config.RelationWorkers,
+		MaxRelationDepth: config.MaxRelationDepth,
 	}, nil
 }
 
+func (c *Connection[T]) softDeleteFilters(filters models.GroupFilter) models.GroupFilter {
+	return prepareSoftDelete(c.SoftDelete, filters)
+}
+
 func (c *Connection[T]) GetTableName() string {
 	return c.Table
 }
@@ -688,87 +1785,242 @@ func (c *Connection[T]) GetOrderColumns() map[string]string {
 	return c.OrderColumns
 }
 
-func (c *Connection[T]) GetConnection() any {
-	return c.Conn
+func (c *Connection[T]) GetConnection() any {
+	return c.Conn
+}
+
+func (c *Connection[T]) AddRelation(relation string, loader repository.RelationLoader) error {
+	if c.RelationLoaders == nil {
+		c.RelationLoaders = make(map[string]repository.RelationLoader)
+	}
+
+	if _, exists := c.RelationLoaders[relation]; exists {
+		return fmt.Errorf("relation loader already exists for relation: %s", relation)
+	}
+
+	c.RelationLoaders[relation] = loader
+	return nil
+}
+
+func (c *Connection[T]) Get(ctx context.Context, filters models.GroupFilter, opts *models.Options) ([]T, error) {
+	ctx, call := takeRelationCall(ctx)
+
+	// Table se valida en cada consulta porque una Connection puede construirse como literal, sin NewConnection
+	if err := validateTableName(c.Table); err != nil {
+		return nil, err
+	}
+
+	if c.SoftDelete != nil && *c.SoftDelete != "" {
+		tmpFilters := prepareSoftDelete(c.SoftDelete, filters)
+		filters = tmpFilters
+	}
+
+	cols := "*"
+
+	if opts != nil && opts.Columns != nil {
+		if err := validateColumns(*opts.Columns); err != nil {
+			return nil, err
+		}
+
+		cols = ""
+		for i, col := range *opts.Columns {
+			if i > 0 {
+				cols += ", "
+			}
+			cols += col
+		}
+	}
+
+	args := []any{}
+
+	allFilters, allVals, counter, err := prepareFilters(filters, 1, c.filterScope(c.Table, 0))
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, allVals...)
+
+	orderBy, err := c.orderClause(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un filtro de texto completo puede pedir el fragmento de ts_headline y ordenar por ts_rank
+	headlineField := ""
+	if search := findFullTextSearch(filters); search != nil {
+		if search.HeadlineField != "" {
+			headline, headlineVals, newCounter, err := search.headlineColumn(counter)
+			if err != nil {
+				return nil, err
+			}
+			counter = newCounter
+
+			cols += ", " + headline
+			args = append(args, headlineVals...)
+			headlineField = search.HeadlineField
+		}
+
+		if search.OrderByRank {
+			rank, rankVals, _, err := search.rankExpression(counter)
+			if err != nil {
+				return nil, err
+			}
+
+			if orderBy == "" {
+				orderBy = " ORDER BY " + rank
+			} else {
+				orderBy = " ORDER BY " + rank + ", " + strings.TrimPrefix(orderBy, " ORDER BY ")
+			}
+			args = append(args, rankVals...)
+		}
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT ")
+	queryBuilder.WriteString(cols)
+	queryBuilder.WriteString(" FROM ")
+	queryBuilder.WriteString(c.Table)
+
+	if allFilters != "" {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(allFilters)
+	}
+
+	queryBuilder.WriteString(orderBy)
+	queryBuilder.WriteString(limitClause(opts))
+
+	return c.fetch(ctx, queryBuilder.String(), args, opts, headlineField, call)
 }
 
-func (c *Connection[T]) AddRelation(relation string, loader repository.RelationLoader) error {
-	if c.RelationLoaders == nil {
-		c.RelationLoaders = make(map[string]repository.RelationLoader)
+// GetPerParent devuelve como máximo perParent filas por cada valor de partitionColumn en una sola consulta,
+// ordenadas dentro de cada grupo según opts.OrderColumn, que es obligatorio
+func (c *Connection[T]) GetPerParent(ctx context.Context, filters models.GroupFilter, partitionColumn string, perParent int, opts *models.Options) ([]T, error) {
+	if perParent <= 0 {
+		return c.Get(ctx, filters, opts)
 	}
 
-	if _, exists := c.RelationLoaders[relation]; exists {
-		return fmt.Errorf("relation loader already exists for relation: %s", relation)
+	if opts == nil || opts.OrderColumn == "" {
+		return nil, fmt.Errorf("GetPerParent requires opts.OrderColumn to pick the rows of each partition")
 	}
 
-	c.RelationLoaders[relation] = loader
-	return nil
-}
+	ctx, call := takeRelationCall(ctx)
+
+	if err := validateTableName(c.Table); err != nil {
+		return nil, err
+	}
 
-func (c *Connection[T]) Get(ctx context.Context, filters models.GroupFilter, opts *models.Options) ([]T, error) {
 	if c.SoftDelete != nil && *c.SoftDelete != "" {
-		tmpFilters := prepareSoftDelete(c.SoftDelete, filters)
-		filters = tmpFilters
+		filters = prepareSoftDelete(c.SoftDelete, filters)
 	}
 
-	cols := "*"
+	if err := validateIdentifier(partitionColumn); err != nil {
+		return nil, err
+	}
 
+	cols := "*"
 	if opts != nil && opts.Columns != nil {
-		cols = ""
-		for i, col := range *opts.Columns {
-			if i > 0 {
-				cols += ", "
-			}
-			cols += col
+		if err := validateColumns(*opts.Columns); err != nil {
+			return nil, err
 		}
+
+		cols = strings.Join(*opts.Columns, ", ")
+	}
+
+	orderBy, err := c.orderClause(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	allFilters, allVals, _, err := prepareFilters(filters, 1, c.filterScope(c.Table, 0))
+	if err != nil {
+		return nil, err
 	}
 
 	var queryBuilder strings.Builder
-	queryBuilder.WriteString("SELECT ")
+	queryBuilder.WriteString("SELECT godbsql_limited.* FROM (SELECT DISTINCT ")
+	queryBuilder.WriteString(partitionColumn)
+	queryBuilder.WriteString(" AS godbsql_partition FROM ")
+	queryBuilder.WriteString(c.Table)
+	if allFilters != "" {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(allFilters)
+	}
+	queryBuilder.WriteString(") AS godbsql_parents CROSS JOIN LATERAL (SELECT ")
 	queryBuilder.WriteString(cols)
 	queryBuilder.WriteString(" FROM ")
 	queryBuilder.WriteString(c.Table)
-
-	args := []any{}
-
-	allFilters, allVals, _ := prepareFilters(filters, 1)
+	queryBuilder.WriteString(" WHERE ")
+	queryBuilder.WriteString(partitionColumn)
+	queryBuilder.WriteString(" = godbsql_parents.godbsql_partition")
+	// Los mismos placeholders se reutilizan en la subconsulta lateral
 	if allFilters != "" {
-		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(" AND (")
 		queryBuilder.WriteString(allFilters)
-		args = append(args, allVals...)
+		queryBuilder.WriteString(")")
 	}
+	queryBuilder.WriteString(orderBy)
+	queryBuilder.WriteString(fmt.Sprintf(" LIMIT %d) AS godbsql_limited", perParent))
+	queryBuilder.WriteString(limitClause(opts))
 
-	if opts != nil {
-		if opts.OrderColumn != "" {
-			orderDir := "ASC"
-			if strings.ToUpper(opts.OrderDir) == "DESC" {
-				orderDir = "DESC"
-			}
+	return c.fetch(ctx, queryBuilder.String(), allVals, opts, "", call)
+}
 
-			if _, ok := c.OrderColumns[opts.OrderColumn]; !ok {
-				return nil, fmt.Errorf("invalid order column: %s", opts.OrderColumn)
-			}
+func (c *Connection[T]) orderClause(opts *models.Options) (string, error) {
+	if opts == nil || opts.OrderColumn == "" {
+		return "", nil
+	}
 
-			queryBuilder.WriteString(" ORDER BY " + opts.OrderColumn + " " + orderDir)
-		}
+	orderDir := "ASC"
+	if strings.ToUpper(opts.OrderDir) == "DESC" {
+		orderDir = "DESC"
+	}
 
-		if opts.Limit > 0 {
-			queryBuilder.WriteString(" LIMIT " + fmt.Sprintf("%d", opts.Limit))
-		}
+	if err := validateIdentifier(opts.OrderColumn); err != nil {
+		return "", err
+	}
 
-		if opts.Offset > 0 {
-			queryBuilder.WriteString(" OFFSET " + fmt.Sprintf("%d", opts.Offset))
-		}
+	if _, ok := c.OrderColumns[opts.OrderColumn]; !ok {
+		return "", fmt.Errorf("invalid order column: %s", opts.OrderColumn)
 	}
 
-	query := queryBuilder.String()
+	return " ORDER BY " + opts.OrderColumn + " " + orderDir, nil
+}
+
+func limitClause(opts *models.Options) string {
+	if opts == nil {
+		return ""
+	}
+
+	clause := ""
+	if opts.Limit > 0 {
+		clause += " LIMIT " + fmt.Sprintf("%d", opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		clause += " OFFSET " + fmt.Sprintf("%d", opts.Offset)
+	}
+
+	return clause
+}
 
+// Ejecuta la consulta, escanea los modelos y carga sus relaciones con las opciones de call. Si headlineField
+// no está vacío la consulta trae al final la columna godbsql_headline y se escribe en ese campo del modelo
+func (c *Connection[T]) fetch(ctx context.Context, query string, args []any, opts *models.Options, headlineField string, call relationCall) ([]T, error) {
 	if goenvars.GetEnvBool("SQL_DEBUG", false) {
 		golog.Log(ctx, "SQL Query:", query)
 		golog.Log(ctx, "SQL Args:", args)
 	}
 
-	rows, err := c.Conn.QueryContext(ctx, query, args...)
+	var tx *models.Transaction
+	if opts != nil {
+		tx = opts.Transaction
+	}
+
+	exec, err := resolveExecutor(c.Conn, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, godb.ErrNoDocumentsFound
@@ -784,16 +2036,37 @@ func (c *Connection[T]) Get(ctx context.Context, filters models.GroupFilter, opt
 		val := reflect.New(reflect.TypeOf(newModelT).Elem())
 		newModelT = val.Interface().(T)
 
+		var scanner Scannable = rows
+		var headline sql.NullString
+		if headlineField != "" {
+			scanner = extraColumnsScanner{Scannable: rows, extra: []any{&headline}}
+		}
+
 		if opts == nil {
-			if err := scanRow(rows, &newModelT, nil); err != nil {
+			if err := scanRow(scanner, &newModelT, nil); err != nil {
 				return nil, err
 			}
 		} else {
-			if err := scanRow(rows, &newModelT, opts.Columns); err != nil {
+			if err := scanRow(scanner, &newModelT, opts.Columns); err != nil {
 				return nil, err
 			}
 		}
 
+		if headlineField != "" {
+			field := val.Elem().FieldByName(headlineField)
+			if !field.IsValid() || !field.CanSet() {
+				return nil, fmt.Errorf("invalid headline field: %s", headlineField)
+			}
+
+			var value any
+			if headline.Valid {
+				value = headline.String
+			}
+			if err := setFieldValue(field, value); err != nil {
+				return nil, fmt.Errorf("failed to set headline field %s: %w", headlineField, err)
+			}
+		}
+
 		models = append(models, newModelT)
 	}
 
@@ -801,38 +2074,211 @@ func (c *Connection[T]) Get(ctx context.Context, filters models.GroupFilter, opt
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
-	if opts != nil && len(opts.Relations) > 0 && c.RelationLoaders != nil {
-		modelPointers := make([]*T, len(models))
-		for i := range models {
-			modelPointers[i] = &models[i]
+	if opts != nil && len(opts.Relations) > 0 || len(call.aggregates) > 0 {
+		var relations []string
+		if opts != nil {
+			relations = opts.Relations
 		}
 
-		anyModels := make([]any, len(modelPointers))
-		for i, m := range modelPointers {
-			anyModels[i] = m
+		if err := c.loadRelations(ctx, models, relations, tx, call); err != nil {
+			return nil, err
+		}
+	}
+
+	return models, nil
+}
+
+// LoadRelations carga relaciones (con notación punto) y agregados sobre modelos ya obtenidos,
+// por ejemplo los devueltos por Create, Update o una caché, con el mismo despacho que Get
+func (c *Connection[T]) LoadRelations(ctx context.Context, items []T, relations []string, tx *models.Transaction) error {
+	ctx, call := takeRelationCall(ctx)
+
+	return c.loadRelations(ctx, items, relations, tx, call)
+}
+
+func (c *Connection[T]) loadRelations(ctx context.Context, items []T, relations []string, tx *models.Transaction, call relationCall) error {
+	maxDepth := c.MaxRelationDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxRelationDepth
+	}
+
+	// Cada segmento se carga una sola vez con todas sus subrutas juntas
+	nodes, err := buildRelationTree(relations, maxDepth)
+	if err != nil {
+		return err
+	}
+
+	if err := call.validateAggregatePaths(nodes); err != nil {
+		return err
+	}
+
+	if err := validateRelationTargets(nodes, c.RelationLoaders, call.levelAggregates()); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	anyModels := make([]any, len(items))
+	for i := range items {
+		anyModels[i] = &items[i]
+	}
+
+	if len(nodes) > 0 && c.RelationLoaders != nil {
+		if len(relationChainFrom(ctx)) >= maxDepth {
+			return fmt.Errorf("relation path exceeds max depth of %d", maxDepth)
 		}
 
-		for _, relation := range opts.Relations {
-			childs := &[]string{}
-			if strings.Contains(relation, ".") {
-				tmp_items := strings.Split(relation, ".")
-				relation = tmp_items[0]
-				next_childs := strings.Join(tmp_items[1:], ".")
-				*childs = []string{next_childs}
+		// Las rutas que escriben el mismo campo del padre (el mismo loader, o loaders distintos con el mismo
+		// ContainerField) se agrupan para que nunca lo escriban en paralelo
+		groups := []*relationGroup{}
+		groupForTarget := map[any]*relationGroup{}
+		for _, node := range nodes {
+			loader, ok := c.RelationLoaders[node.name]
+			if !ok || loader == nil {
+				return fmt.Errorf("relation loader not found for relation: %s", node.name)
 			}
 
-			loader, ok := c.RelationLoaders[relation]
-			if !ok {
-				return nil, fmt.Errorf("relation loader not found for relation: %s", relation)
+			target, hasTarget := relationGroupKey(loader)
+
+			var group *relationGroup
+			if hasTarget {
+				group = groupForTarget[target]
 			}
 
-			if err := loader.Load(ctx, anyModels, childs); err != nil {
-				return nil, fmt.Errorf("failed to load relation %s: %w", relation, err)
+			if group == nil {
+				group = &relationGroup{}
+				groups = append(groups, group)
+				if hasTarget {
+					groupForTarget[target] = group
+				}
 			}
+
+			childs := append([]string{}, node.childs...)
+			group.loaders = append(group.loaders, loader)
+			group.relations = append(group.relations, node.name)
+			group.childs = append(group.childs, &childs)
+		}
+
+		tasks := make([]func(ctx context.Context) error, 0, len(groups))
+		for _, group := range groups {
+			tasks = append(tasks, func(ctx context.Context) error {
+				for i, relation := range group.relations {
+					loadOpts := &RelationLoadOptions{
+						Transaction: tx,
+						Constraint:  call.constraintFor(relation),
+					}
+
+					childCtx := withRelationChain(withRelationCall(ctx, call.scope(relation)), group.loaders[i])
+					if err := loadRelation(childCtx, group.loaders[i], anyModels, group.childs[i], loadOpts); err != nil {
+						return fmt.Errorf("failed to load relation %s: %w", relation, err)
+					}
+				}
+
+				return nil
+			})
+		}
+
+		// Un *sql.Tx usa una sola conexión, dentro de una transacción los loaders van en serie
+		workers := c.RelationWorkers
+		if tx != nil {
+			workers = 1
+		}
+
+		if err := runRelationTasks(ctx, workers, tasks); err != nil {
+			return err
 		}
 	}
 
-	return models, nil
+	for _, aggregate := range call.levelAggregates() {
+		loader, ok := c.RelationLoaders[aggregate.Relation]
+		if !ok {
+			return fmt.Errorf("relation loader not found for relation: %s", aggregate.Relation)
+		}
+
+		aggregator, ok := loader.(RelationAggregator)
+		if !ok {
+			return fmt.Errorf("relation %s does not support aggregates", aggregate.Relation)
+		}
+
+		if err := aggregator.Aggregate(ctx, anyModels, aggregate, &RelationLoadOptions{Transaction: tx}); err != nil {
+			return fmt.Errorf("failed to aggregate relation %s: %w", aggregate.Relation, err)
+		}
+	}
+
+	return nil
+}
+
+type relationGroup struct {
+	loaders   []repository.RelationLoader
+	relations []string
+	childs    []*[]string
+}
+
+// Ejecuta las tareas con como máximo workers en paralelo; el primer error cancela el contexto de las demás
+func runRelationTasks(ctx context.Context, workers int, tasks []func(ctx context.Context) error) error {
+	if workers <= 1 || len(tasks) <= 1 {
+		for _, task := range tasks {
+			if err := task(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	setErr := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	semaphore := make(chan struct{}, workers)
+	for _, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				setErr(ctx.Err())
+				return
+			}
+			defer func() { <-semaphore }()
+
+			if err := ctx.Err(); err != nil {
+				setErr(err)
+				return
+			}
+
+			if err := task(ctx); err != nil {
+				setErr(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// LoadRelations carga relaciones sobre modelos ya obtenidos a través de un repository.DriverConnection
+func LoadRelations[T Model](ctx context.Context, repo repository.DriverConnection[T], items []T, relations []string, tx *models.Transaction) error {
+	conn, ok := repo.(*Connection[T])
+	if !ok {
+		return fmt.Errorf("repository %T does not support loading relations", repo)
+	}
+
+	return conn.LoadRelations(ctx, items, relations, tx)
 }
 
 func (c *Connection[T]) GetOne(ctx context.Context, filters models.GroupFilter, opts *models.Options) (T, error) {
@@ -861,6 +2307,10 @@ func (c *Connection[T]) GetOne(ctx context.Context, filters models.GroupFilter,
 func (c *Connection[T]) Create(ctx context.Context, data map[string]any, opts *models.Options) (T, error) {
 	var zero T
 
+	if err := validateTableName(c.Table); err != nil {
+		return zero, err
+	}
+
 	newUuid, err := uuid.NewV7()
 	if err != nil {
 		return zero, err
@@ -895,6 +2345,10 @@ func (c *Connection[T]) Create(ctx context.Context, data map[string]any, opts *m
 		data["updated_at"] = now
 	}
 
+	if err := validateDataColumns(data); err != nil {
+		return zero, err
+	}
+
 	columns := make([]string, 0, len(data))
 	values := make([]any, 0, len(data))
 	placeholders := make([]string, 0, len(data))
@@ -952,11 +2406,19 @@ func (c *Connection[T]) CreateMany(ctx context.Context, dataList []map[string]an
 		return []T{}, nil
 	}
 
+	if err := validateTableName(c.Table); err != nil {
+		return nil, err
+	}
+
 	if opts == nil {
 		opts = &models.Options{}
 	}
 
 	data := dataList[0]
+	if err := validateDataColumns(data); err != nil {
+		return nil, err
+	}
+
 	columns := make([]string, 0, len(data))
 	for k := range data {
 		columns = append(columns, k)
@@ -1016,6 +2478,11 @@ func (c *Connection[T]) CreateMany(ctx context.Context, dataList []map[string]an
 }
 
 func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter, data map[string]any, opts *models.Options) (T, error) {
+	if err := validateTableName(c.Table); err != nil {
+		var zero T
+		return zero, err
+	}
+
 	if c.SoftDelete != nil && *c.SoftDelete != "" {
 		tmpFilters := prepareSoftDelete(c.SoftDelete, filters)
 		filters = tmpFilters
@@ -1037,6 +2504,12 @@ func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter,
 		data["updated_at"] = time.Now().UTC()
 	}
 
+	var zero T
+
+	if err := validateDataColumns(data); err != nil {
+		return zero, err
+	}
+
 	setParts := make([]string, 0, len(data))
 	vals := []any{}
 	items := 1
@@ -1057,7 +2530,10 @@ func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter,
 	queryBuilder.WriteString(" SET ")
 	queryBuilder.WriteString(strings.Join(setParts, ", "))
 
-	allFilters, allVals, _ := prepareFilters(filters, items)
+	allFilters, allVals, _, err := prepareFilters(filters, items, c.filterScope(c.Table, 0))
+	if err != nil {
+		return zero, err
+	}
 	if allFilters != "" {
 		queryBuilder.WriteString(" WHERE ")
 		queryBuilder.WriteString(allFilters)
@@ -1071,8 +2547,6 @@ func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter,
 		golog.Log(ctx, "SQL Values:", vals)
 	}
 
-	var zero T
-
 	if opts.Transaction == nil {
 		_, err := c.Conn.ExecContext(ctx, query, vals...)
 		if err != nil {
@@ -1110,7 +2584,10 @@ func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter,
 		return empty, nil
 	}
 
-	result, err := c.GetOne(ctx, filters, nil)
+	// Las restricciones y agregados de relaciones del contexto no aplican a la fila devuelta por Update
+	ctx, _ = takeRelationCall(ctx)
+
+	result, err := c.GetOne(ctx, filters, &models.Options{Transaction: opts.Transaction})
 	if err != nil {
 		return zero, err
 	}
@@ -1119,6 +2596,10 @@ func (c *Connection[T]) Update(ctx context.Context, filters models.GroupFilter,
 }
 
 func (c *Connection[T]) Delete(ctx context.Context, filters models.GroupFilter) error {
+	if err := validateTableName(c.Table); err != nil {
+		return err
+	}
+
 	if c.SoftDelete != nil && *c.SoftDelete != "" {
 		nop := false
 		opts := &models.Options{
@@ -1138,7 +2619,10 @@ func (c *Connection[T]) Delete(ctx context.Context, filters models.GroupFilter)
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(fmt.Sprintf("DELETE FROM %s", c.Table))
 
-	allFilters, allVals, _ := prepareFilters(filters, 1)
+	allFilters, allVals, _, err := prepareFilters(filters, 1, c.filterScope(c.Table, 0))
+	if err != nil {
+		return err
+	}
 	if allFilters != "" {
 		queryBuilder.WriteString(fmt.Sprintf(" WHERE %s", allFilters))
 	}
@@ -1168,6 +2652,10 @@ func (c *Connection[T]) Delete(ctx context.Context, filters models.GroupFilter)
 }
 
 func (c *Connection[T]) Count(ctx context.Context, filters models.GroupFilter) (int64, error) {
+	if err := validateTableName(c.Table); err != nil {
+		return 0, err
+	}
+
 	if c.SoftDelete != nil && *c.SoftDelete != "" {
 		tmpFilters := prepareSoftDelete(c.SoftDelete, filters)
 		filters = tmpFilters
@@ -1178,7 +2666,10 @@ func (c *Connection[T]) Count(ctx context.Context, filters models.GroupFilter) (
 
 	args := []any{}
 
-	allFilters, allVals, _ := prepareFilters(filters, 1)
+	allFilters, allVals, _, err := prepareFilters(filters, 1, c.filterScope(c.Table, 0))
+	if err != nil {
+		return 0, err
+	}
 	if allFilters != "" {
 		queryBuilder.WriteString(" WHERE ")
 		queryBuilder.WriteString(allFilters)
@@ -1193,7 +2684,7 @@ func (c *Connection[T]) Count(ctx context.Context, filters models.GroupFilter) (
 	}
 
 	var count int64
-	err := c.Conn.QueryRowContext(ctx, query, args...).Scan(&count)
+	err = c.Conn.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute query on connection \"%s\": %w", c.Name, err)
 	}
@@ -1239,7 +2730,7 @@ func (c *Connection[T]) TransactionRollback(ctx context.Context, tx *models.Tran
 	return nil
 }
 
-func prepareFilters(filters models.GroupFilter, counter int) (string, []any, int) {
+func prepareFilters(filters models.GroupFilter, counter int, scope *filterScope) (string, []any, int, error) {
 	ctx := context.Background()
 	var queryBuilder strings.Builder
 
@@ -1247,6 +2738,18 @@ func prepareFilters(filters models.GroupFilter, counter int) (string, []any, int
 		counter = 1
 	}
 
+	// El operador del grupo se escribe en el SQL y puede venir de la petición: solo AND y OR
+	groupOperator := OperatorAnd
+	if filters.Operator != "" {
+		switch strings.ToUpper(strings.TrimSpace(filters.Operator)) {
+		case OperatorAnd:
+		case OperatorOr:
+			groupOperator = OperatorOr
+		default:
+			return "", nil, counter, fmt.Errorf("unsupported group operator: %q", filters.Operator)
+		}
+	}
+
 	vals := []any{}
 	for _, tmpFilter := range filters.Filters {
 
@@ -1256,60 +2759,106 @@ func prepareFilters(filters models.GroupFilter, counter int) (string, []any, int
 		if filter, ok := tmpFilter.(models.Filter); ok {
 			comparator := "="
 			if filter.Comparator != nil {
-				comparator = *filter.Comparator
+				comparator = normalizeComparator(*filter.Comparator)
 			}
 
-			if comparator != ComparatorIsNull && comparator != ComparatorIsNotNull && comparator != ComparatorIn && comparator != ComparatorNotIn {
-				currentParts.WriteString(fmt.Sprintf("%s %s $%d", filter.Key, comparator, counter))
-				currentVals = append(currentVals, filter.Value)
-
-				counter++
-			} else if comparator == ComparatorIn || comparator == ComparatorNotIn {
+			if comparator == ComparatorIn || comparator == ComparatorNotIn {
 				golog.Log(ctx, "models.Filter not support 'IN' or 'NOT IN' comparator, use models.FilterMultipleValue")
 				continue
-			} else {
-				currentParts.WriteString(fmt.Sprintf("%s %s", filter.Key, comparator))
 			}
+
+			if err := validateIdentifier(filter.Key); err != nil {
+				return "", nil, counter, err
+			}
+
+			comparison, comparisonVals, newCounter, err := prepareComparison(filter.Key, comparator, filter.Value, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(comparison)
+			currentVals = append(currentVals, comparisonVals...)
 		} else if multiFilter, ok := tmpFilter.(models.FilterMultipleValue); ok {
 			comparator := "IN"
 			if multiFilter.Comparator != nil {
-				comparator = *multiFilter.Comparator
+				comparator = normalizeComparator(*multiFilter.Comparator)
 			}
 
-			if comparator == ComparatorIn || comparator == ComparatorNotIn {
-				currentParts.WriteString(fmt.Sprintf("%s %s (", multiFilter.Key, comparator))
-				subitems := 1
-				for _, v := range multiFilter.Values {
-					if subitems > 1 {
-						currentParts.WriteString(", ")
-					}
-
-					currentParts.WriteString(fmt.Sprintf("$%d", counter))
+			if comparator != ComparatorIn && comparator != ComparatorNotIn {
+				return "", nil, counter, fmt.Errorf("unsupported comparator for multiple values: %q", comparator)
+			}
 
-					currentVals = append(currentVals, v)
-					counter++
-					subitems++
-				}
-				currentParts.WriteString(")")
+			if err := validateIdentifier(multiFilter.Key); err != nil {
+				return "", nil, counter, err
 			}
+
+			inQuery, inVals, newCounter := inClause(multiFilter.Key, comparator, multiFilter.Values, counter)
+			counter = newCounter
+
+			currentParts.WriteString(inQuery)
+			currentVals = append(currentVals, inVals...)
 		} else if groupFilter, ok := tmpFilter.(models.GroupFilter); ok {
-			subQuery, subVals, newCounter := prepareFilters(groupFilter, counter)
+			subQuery, subVals, newCounter, err := prepareFilters(groupFilter, counter, scope)
+			if err != nil {
+				return "", nil, counter, err
+			}
 			counter = newCounter
 
 			if subQuery != "" {
 				currentParts.WriteString(fmt.Sprintf("(%s)", subQuery))
 				currentVals = append(currentVals, subVals...)
 			}
+		} else if jsonFilter, ok := tmpFilter.(JSONFilter); ok {
+			jsonQuery, jsonVals, newCounter, err := prepareJSONFilter(jsonFilter, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(jsonQuery)
+			currentVals = append(currentVals, jsonVals...)
+		} else if fullTextFilter, ok := tmpFilter.(FullTextFilter); ok {
+			fullTextQuery, fullTextVals, newCounter, err := prepareFullTextFilter(fullTextFilter, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(fullTextQuery)
+			currentVals = append(currentVals, fullTextVals...)
+		} else if subqueryFilter, ok := tmpFilter.(SubqueryFilter); ok {
+			subQuery, subVals, newCounter, err := prepareSubqueryFilter(subqueryFilter, scope, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(subQuery)
+			currentVals = append(currentVals, subVals...)
+		} else if whereHas, ok := tmpFilter.(WhereHas); ok {
+			existsQuery, existsVals, newCounter, err := compileWhereHas(whereHas.Relation, whereHas.Filters, false, scope, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(existsQuery)
+			currentVals = append(currentVals, existsVals...)
+		} else if whereDoesntHave, ok := tmpFilter.(WhereDoesntHave); ok {
+			existsQuery, existsVals, newCounter, err := compileWhereHas(whereDoesntHave.Relation, whereDoesntHave.Filters, true, scope, counter)
+			if err != nil {
+				return "", nil, counter, err
+			}
+			counter = newCounter
+
+			currentParts.WriteString(existsQuery)
+			currentVals = append(currentVals, existsVals...)
 		}
 
 		if currentParts.Len() > 0 {
 			// golog.Printf("queryBuilderLen: %d\n", queryBuilder.Len())
 			if queryBuilder.Len() > 0 {
-				groupOperator := OperatorAnd
-				if filters.Operator != "" {
-					groupOperator = filters.Operator
-				}
-				// golog.Printf("Using group operator: %s\n", groupOperator)
 				queryBuilder.WriteString(fmt.Sprintf(" %s ", groupOperator))
 			}
 
@@ -1318,7 +2867,45 @@ func prepareFilters(filters models.GroupFilter, counter int) (string, []any, int
 		}
 	}
 
-	return queryBuilder.String(), vals, counter
+	return queryBuilder.String(), vals, counter, nil
+}
+
+// Arma "key IN ($n, ...)" sin valores repetidos; con más de InListArrayThreshold valores usa un solo
+// parámetro de tipo arreglo para no rebasar el límite de parámetros de Postgres
+func inClause(key, comparator string, values []any, counter int) (string, []any, int) {
+	values = uniqueKeys(values)
+
+	if len(values) == 0 {
+		// "IN ()" no es SQL válido
+		if comparator == ComparatorNotIn {
+			return "1 = 1", nil, counter
+		}
+		return "1 = 0", nil, counter
+	}
+
+	if len(values) > InListArrayThreshold {
+		arrayComparator := "= ANY"
+		if comparator == ComparatorNotIn {
+			arrayComparator = "<> ALL"
+		}
+
+		// pq.Array convierte cada elemento con su driver.Valuer, uuid.UUID incluido
+		return fmt.Sprintf("%s %s($%d)", key, arrayComparator, counter), []any{pq.Array(values)}, counter + 1
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(fmt.Sprintf("%s %s (", key, comparator))
+	for i := range values {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+
+		queryBuilder.WriteString(fmt.Sprintf("$%d", counter))
+		counter++
+	}
+	queryBuilder.WriteString(")")
+
+	return queryBuilder.String(), values, counter
 }
 
 func prepareSoftDelete(softDelete *string, filters models.GroupFilter) models.GroupFilter {
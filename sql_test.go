@@ -0,0 +1,1242 @@
+package godbsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInClause(t *testing.T) {
+	tests := []struct {
+		name          string
+		comparator    string
+		values        []any
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "lista",
+			comparator:    ComparatorIn,
+			values:        []any{1, 2, 3},
+			counter:       1,
+			expectedQuery: "id IN ($1, $2, $3)",
+			expectedArgs:  []any{1, 2, 3},
+			expectedNext:  4,
+		},
+		{
+			name:          "sin repetidos",
+			comparator:    ComparatorIn,
+			values:        []any{int32(1), int64(1), 2},
+			counter:       3,
+			expectedQuery: "id IN ($3, $4)",
+			expectedArgs:  []any{int32(1), 2},
+			expectedNext:  5,
+		},
+		{
+			name:          "NOT IN",
+			comparator:    ComparatorNotIn,
+			values:        []any{"a"},
+			counter:       1,
+			expectedQuery: "id NOT IN ($1)",
+			expectedArgs:  []any{"a"},
+			expectedNext:  2,
+		},
+		{
+			name:          "IN vacío",
+			comparator:    ComparatorIn,
+			values:        []any{},
+			counter:       2,
+			expectedQuery: "1 = 0",
+			expectedNext:  2,
+		},
+		{
+			name:          "NOT IN vacío",
+			comparator:    ComparatorNotIn,
+			values:        nil,
+			counter:       2,
+			expectedQuery: "1 = 1",
+			expectedNext:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next := inClause("id", tt.comparator, tt.values, tt.counter)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestInClauseArrayAboveThreshold(t *testing.T) {
+	values := make([]any, 0, InListArrayThreshold+1)
+	for i := 0; i <= InListArrayThreshold; i++ {
+		values = append(values, i)
+	}
+
+	query, args, next := inClause("id", ComparatorIn, values, 2)
+	assert.Equal(t, "id = ANY($2)", query)
+	assert.Equal(t, []any{pq.Array(values)}, args)
+	assert.Equal(t, 3, next)
+
+	query, _, next = inClause("id", ComparatorNotIn, values, 1)
+	assert.Equal(t, "id <> ALL($1)", query)
+	assert.Equal(t, 2, next)
+}
+
+func TestInClauseArrayOfUUIDs(t *testing.T) {
+	ids := make([]any, 0, InListArrayThreshold+1)
+	for i := 0; i <= InListArrayThreshold; i++ {
+		ids = append(ids, uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i)))
+	}
+
+	_, args, _ := inClause("id", ComparatorIn, ids, 1)
+	require.Len(t, args, 1)
+
+	value, err := args[0].(driver.Valuer).Value()
+	require.NoError(t, err)
+	assert.Contains(t, value, `"00000000-0000-0000-0000-000000000000","00000000-0000-0000-0000-000000000001"`)
+}
+
+var errFakeNotImplemented = errors.New("not implemented in fake repository")
+
+// Repositorio en memoria para probar los loaders: resuelve los filtros de llaves (IN e igualdad)
+// sobre rows y registra los filtros de cada Get
+type fakeRepository[T Model] struct {
+	table string
+	rows  []T
+	gets  []models.GroupFilter
+}
+
+var _ repository.DriverConnection[*loaderTestProfile] = (*fakeRepository[*loaderTestProfile])(nil)
+
+func (r *fakeRepository[T]) GetTableName() string {
+	return r.table
+}
+
+func (r *fakeRepository[T]) GetOrderColumns() map[string]string {
+	return map[string]string{}
+}
+
+func (r *fakeRepository[T]) GetConnection() any {
+	return nil
+}
+
+func (r *fakeRepository[T]) AddRelation(relationName string, loader repository.RelationLoader) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) Get(ctx context.Context, filters models.GroupFilter, opts *models.Options) ([]T, error) {
+	r.gets = append(r.gets, filters)
+
+	result := []T{}
+	for _, row := range r.rows {
+		if fakeRowMatches(row, filters) {
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *fakeRepository[T]) GetOne(ctx context.Context, filters models.GroupFilter, opts *models.Options) (T, error) {
+	var zero T
+	return zero, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) Create(ctx context.Context, data map[string]any, opts *models.Options) (T, error) {
+	var zero T
+	return zero, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) CreateMany(ctx context.Context, data []map[string]any, opts *models.Options) ([]T, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) Update(ctx context.Context, filters models.GroupFilter, data map[string]any, opts *models.Options) (T, error) {
+	var zero T
+	return zero, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) Delete(ctx context.Context, filters models.GroupFilter) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) Count(ctx context.Context, filters models.GroupFilter) (int64, error) {
+	return 0, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) TransactionStart(ctx context.Context) (*models.Transaction, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) TransactionCommit(ctx context.Context, trans *models.Transaction) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeRepository[T]) TransactionRollback(ctx context.Context, trans *models.Transaction) error {
+	return errFakeNotImplemented
+}
+
+// Filtros en AND: FilterMultipleValue como IN, Filter como igualdad y GroupFilter anidados
+func fakeRowMatches(row any, filters models.GroupFilter) bool {
+	for _, tmpFilter := range filters.Filters {
+		switch filter := tmpFilter.(type) {
+		case models.FilterMultipleValue:
+			key := fakeRowKey(row, filter.Key)
+			found := false
+			for _, value := range filter.Values {
+				if normalizeKey(value) == key {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case models.Filter:
+			if normalizeKey(filter.Value) != fakeRowKey(row, filter.Key) {
+				return false
+			}
+		case models.GroupFilter:
+			if !fakeRowMatches(row, filter) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func fakeRowKey(row any, column string) any {
+	val := reflect.ValueOf(row)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	return normalizeKey(val.FieldByName(prepareForeignKey(column)).Interface())
+}
+
+type loaderTestUser struct {
+	Id       int64
+	TeamId   *int64
+	Profile  *loaderTestProfile
+	Team     *loaderTestTeam
+	Comments []*loaderTestComment
+	Notes    []*loaderTestNote
+	Roles    []*loaderTestRole
+}
+
+func (m *loaderTestUser) ScanFields() []any {
+	return []any{&m.Id}
+}
+
+type loaderTestProfile struct {
+	Id     int64
+	UserId int64
+}
+
+func (m *loaderTestProfile) ScanFields() []any {
+	return []any{&m.Id, &m.UserId}
+}
+
+func TestOnetoOneLoaderLoadsEveryParent(t *testing.T) {
+	profiles := &fakeRepository[*loaderTestProfile]{
+		table: "profiles",
+		rows: []*loaderTestProfile{
+			{Id: 10, UserId: 1},
+			{Id: 30, UserId: 3},
+			{Id: 31, UserId: 3},
+			{Id: 40, UserId: 4},
+		},
+	}
+	loader := &OnetoOneLoader[*loaderTestUser, *loaderTestProfile]{
+		Repository: profiles, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Profile",
+	}
+
+	users := []*loaderTestUser{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 1}}
+	parents := []any{users[0], users[1], users[2], users[3]}
+
+	require.NoError(t, loader.Load(context.Background(), parents, nil))
+
+	// Una sola consulta con la llave de todos los padres, sin repetir
+	require.Len(t, profiles.gets, 1)
+	require.Len(t, profiles.gets[0].Filters, 1)
+	in := profiles.gets[0].Filters[0].(models.FilterMultipleValue)
+	assert.Equal(t, "user_id", in.Key)
+	assert.Equal(t, []any{int64(1), int64(2), int64(3)}, in.Values)
+
+	require.NotNil(t, users[0].Profile)
+	assert.Equal(t, int64(10), users[0].Profile.Id)
+	assert.Nil(t, users[1].Profile)
+	require.NotNil(t, users[2].Profile)
+	assert.Equal(t, int64(30), users[2].Profile.Id, "el primer hijo encontrado gana")
+	require.NotNil(t, users[3].Profile)
+	assert.Same(t, users[0].Profile, users[3].Profile)
+}
+
+type loaderTestTeam struct {
+	Id int64
+}
+
+func (m *loaderTestTeam) ScanFields() []any {
+	return []any{&m.Id}
+}
+
+func TestBelongsToLoaderAssignsOwnerByForeignKey(t *testing.T) {
+	teams := &fakeRepository[*loaderTestTeam]{
+		table: "teams",
+		rows:  []*loaderTestTeam{{Id: 5}, {Id: 7}},
+	}
+	loader := &BelongsToLoader[*loaderTestUser, *loaderTestTeam]{
+		Repository: teams, ParentFkField: "TeamId", ChildKey: "id", ContainerField: "Team",
+	}
+
+	five, nine := int64(5), int64(9)
+	users := []*loaderTestUser{{Id: 1, TeamId: &five}, {Id: 2}, {Id: 3, TeamId: &five}, {Id: 4, TeamId: &nine}}
+
+	require.NoError(t, loader.Load(context.Background(), []any{users[0], users[1], users[2], users[3]}, nil))
+
+	// Las llaves nulas no se consultan y las repetidas van una sola vez
+	require.Len(t, teams.gets, 1)
+	in := teams.gets[0].Filters[0].(models.FilterMultipleValue)
+	assert.Equal(t, "id", in.Key)
+	assert.Equal(t, []any{int64(5), int64(9)}, in.Values)
+
+	require.NotNil(t, users[0].Team)
+	assert.Equal(t, int64(5), users[0].Team.Id)
+	assert.Nil(t, users[1].Team)
+	assert.Same(t, users[0].Team, users[2].Team)
+	assert.Nil(t, users[3].Team)
+}
+
+func TestBelongsToLoaderWithoutForeignKeysSkipsQuery(t *testing.T) {
+	teams := &fakeRepository[*loaderTestTeam]{table: "teams"}
+	loader := &BelongsToLoader[*loaderTestUser, *loaderTestTeam]{
+		Repository: teams, ParentFkField: "TeamId", ChildKey: "id", ContainerField: "Team",
+	}
+
+	require.NoError(t, loader.Load(context.Background(), []any{&loaderTestUser{Id: 1}}, nil))
+	assert.Empty(t, teams.gets)
+}
+
+// Consulta recibida por fakeDB con sus argumentos tal como los pasó el código
+type fakeQuery struct {
+	query string
+	args  []any
+}
+
+// Filas con que fakeDB responde a una consulta
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// Base de datos en memoria detrás de un *sql.DB real: registra cada consulta y responde con handler
+type fakeDB struct {
+	mu        sync.Mutex
+	handler   func(query string, args []any) fakeResult
+	queries   []fakeQuery
+	begins    int
+	commits   int
+	rollbacks int
+}
+
+func newFakeDB(handler func(query string, args []any) fakeResult) (*sql.DB, *fakeDB) {
+	fake := &fakeDB{handler: handler}
+	return sql.OpenDB(fake), fake
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+func (f *fakeDB) recorded() []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeQuery{}, f.queries...)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errFakeNotImplemented
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	c.db.begins++
+	return fakeTx{db: c.db}, nil
+}
+
+// Acepta cualquier argumento sin convertirlo, así las pruebas ven los valores originales
+func (c *fakeConn) CheckNamedValue(value *driver.NamedValue) error {
+	return nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	c.db.mu.Lock()
+	c.db.queries = append(c.db.queries, fakeQuery{query: query, args: values})
+	handler := c.db.handler
+	c.db.mu.Unlock()
+
+	result := fakeResult{}
+	if handler != nil {
+		result = handler(query, values)
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (t fakeTx) Commit() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+
+	t.db.commits++
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+
+	t.db.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+type loaderTestComment struct {
+	Id     int64
+	PostId int64
+}
+
+func (m *loaderTestComment) ScanFields() []any {
+	return []any{&m.Id, &m.PostId}
+}
+
+func TestHasManyThroughLoaderGroupsChildrenWithoutRepeating(t *testing.T) {
+	// La tabla intermedia repite (1, 100): el comentario del post 100 debe llegar una sola vez al usuario 1
+	conn, db := newFakeDB(func(query string, args []any) fakeResult {
+		return fakeResult{
+			columns: []string{"user_id", "id"},
+			rows:    [][]driver.Value{{int64(1), int64(100)}, {int64(1), int64(100)}, {int64(1), int64(101)}, {int64(2), int64(100)}},
+		}
+	})
+	comments := &fakeRepository[*loaderTestComment]{
+		table: "comments",
+		rows: []*loaderTestComment{
+			{Id: 1, PostId: 100},
+			{Id: 2, PostId: 101},
+			{Id: 3, PostId: 100},
+			{Id: 4, PostId: 999},
+		},
+	}
+	loader := &HasManyThroughLoader[*loaderTestUser, *loaderTestComment]{
+		Repository: comments, Connection: conn, ParentKey: "Id", ThroughTable: "posts",
+		ThroughParentKey: "user_id", ThroughKey: "id", ChildFkField: "post_id", ContainerField: "Comments",
+	}
+
+	users := []*loaderTestUser{{Id: 1}, {Id: 2}, {Id: 3}}
+	require.NoError(t, loader.Load(context.Background(), []any{users[0], users[1], users[2]}, nil))
+
+	queries := db.recorded()
+	require.Len(t, queries, 1)
+	assert.Equal(t, "SELECT user_id, id FROM posts WHERE user_id IN ($1, $2, $3)", queries[0].query)
+	assert.Equal(t, []any{int64(1), int64(2), int64(3)}, queries[0].args)
+
+	require.Len(t, comments.gets, 1)
+	in := comments.gets[0].Filters[0].(models.FilterMultipleValue)
+	assert.Equal(t, "post_id", in.Key)
+	assert.Equal(t, []any{int64(100), int64(101)}, in.Values)
+
+	commentIds := func(user *loaderTestUser) []int64 {
+		ids := []int64{}
+		for _, comment := range user.Comments {
+			ids = append(ids, comment.Id)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{1, 2, 3}, commentIds(users[0]))
+	assert.Equal(t, []int64{1, 3}, commentIds(users[1]))
+	assert.Nil(t, users[2].Comments)
+}
+
+type loaderTestNote struct {
+	Id          int64
+	NotableId   int64
+	NotableType string
+	Notable     Model
+}
+
+func (m *loaderTestNote) ScanFields() []any {
+	return []any{&m.Id, &m.NotableId, &m.NotableType}
+}
+
+type loaderTestPost struct {
+	Id       int64
+	Comments []*loaderTestComment
+}
+
+func (m *loaderTestPost) ScanFields() []any {
+	return []any{&m.Id}
+}
+
+func TestMorphManyLoaderFiltersByMorphType(t *testing.T) {
+	notes := &fakeRepository[*loaderTestNote]{
+		table: "notes",
+		rows: []*loaderTestNote{
+			{Id: 1, NotableId: 1, NotableType: "user"},
+			{Id: 2, NotableId: 1, NotableType: "team"},
+			{Id: 3, NotableId: 2, NotableType: "user"},
+			{Id: 4, NotableId: 1, NotableType: "user"},
+		},
+	}
+	loader := &MorphManyLoader[*loaderTestUser, *loaderTestNote]{
+		Repository: notes, ParentField: "Id", MorphIdField: "notable_id", MorphTypeField: "notable_type",
+		MorphType: "user", ContainerField: "Notes",
+	}
+
+	users := []*loaderTestUser{{Id: 1}, {Id: 2}, {Id: 3}}
+	require.NoError(t, loader.Load(context.Background(), []any{users[0], users[1], users[2]}, nil))
+
+	require.Len(t, notes.gets, 1)
+	assert.Equal(t, models.Filter{Key: "notable_type", Value: "user"}, notes.gets[0].Filters[0])
+
+	require.Len(t, users[0].Notes, 2)
+	assert.Equal(t, int64(1), users[0].Notes[0].Id)
+	assert.Equal(t, int64(4), users[0].Notes[1].Id)
+	require.Len(t, users[1].Notes, 1)
+	assert.Equal(t, int64(3), users[1].Notes[0].Id)
+	assert.Nil(t, users[2].Notes)
+}
+
+func TestMorphToLoaderQueriesEachOwnerOnce(t *testing.T) {
+	usersRepo := &fakeRepository[*loaderTestUser]{table: "users", rows: []*loaderTestUser{{Id: 1}, {Id: 2}}}
+	teamsRepo := &fakeRepository[*loaderTestTeam]{table: "teams", rows: []*loaderTestTeam{{Id: 5}}}
+	loader := &MorphToLoader[*loaderTestNote]{
+		MorphIdField: "NotableId", MorphTypeField: "NotableType", ContainerField: "Notable",
+		Owners: map[string]MorphOwner{
+			"user": &MorphOwnerRepository[*loaderTestUser]{Repository: usersRepo, OwnerKey: "id"},
+			"team": &MorphOwnerRepository[*loaderTestTeam]{Repository: teamsRepo, OwnerKey: "id"},
+		},
+	}
+
+	notes := []*loaderTestNote{
+		{Id: 1, NotableId: 1, NotableType: "user"},
+		{Id: 2, NotableId: 5, NotableType: "team"},
+		{Id: 3, NotableId: 99, NotableType: "user"},
+		{Id: 4, NotableId: 1, NotableType: "user"},
+	}
+	require.NoError(t, loader.Load(context.Background(), []any{notes[0], notes[1], notes[2], notes[3]}, nil))
+
+	require.Len(t, usersRepo.gets, 1)
+	assert.Equal(t, []any{int64(1), int64(99)}, usersRepo.gets[0].Filters[0].(models.FilterMultipleValue).Values)
+	require.Len(t, teamsRepo.gets, 1)
+	assert.Equal(t, []any{int64(5)}, teamsRepo.gets[0].Filters[0].(models.FilterMultipleValue).Values)
+
+	require.IsType(t, &loaderTestUser{}, notes[0].Notable)
+	assert.Equal(t, int64(1), notes[0].Notable.(*loaderTestUser).Id)
+	require.IsType(t, &loaderTestTeam{}, notes[1].Notable)
+	assert.Equal(t, int64(5), notes[1].Notable.(*loaderTestTeam).Id)
+	assert.Nil(t, notes[2].Notable)
+	assert.Same(t, notes[0].Notable, notes[3].Notable)
+}
+
+func TestMorphToLoaderRejectsUnknownType(t *testing.T) {
+	loader := &MorphToLoader[*loaderTestNote]{
+		MorphIdField: "NotableId", MorphTypeField: "NotableType", ContainerField: "Notable",
+		Owners: map[string]MorphOwner{},
+	}
+
+	err := loader.Load(context.Background(), []any{&loaderTestNote{Id: 1, NotableId: 1, NotableType: "video"}}, nil)
+	assert.ErrorContains(t, err, "morph owner not registered for type: video")
+}
+
+type loaderTestRole struct {
+	Id    int64
+	Pivot map[string]any
+}
+
+func (m *loaderTestRole) ScanFields() []any {
+	return []any{&m.Id}
+}
+
+func TestManyToManyLoaderWritesPivotColumns(t *testing.T) {
+	conn, db := newFakeDB(func(query string, args []any) fakeResult {
+		return fakeResult{
+			columns: []string{"user_id", "role_id", "granted_at"},
+			rows:    [][]driver.Value{{int64(1), int64(10), "2024-01-01"}, {int64(2), int64(10), "2024-02-01"}, {int64(1), int64(11), "2024-03-01"}},
+		}
+	})
+	roles := &fakeRepository[*loaderTestRole]{table: "roles", rows: []*loaderTestRole{{Id: 10}, {Id: 11}}}
+	loader := &ManyToManyLoader[*loaderTestUser, *loaderTestRole]{
+		Repository: roles, Connection: conn, ParentKey: "Id", ChildKey: "Id", PivoteTable: "role_users",
+		PivoteParentKey: "user_id", PivoteChildKey: "role_id", ContainerField: "Roles",
+		PivoteColumns: []string{"granted_at"}, PivoteField: "Pivot",
+	}
+
+	users := []*loaderTestUser{{Id: 1}, {Id: 2}, {Id: 3}}
+	require.NoError(t, loader.Load(context.Background(), []any{users[0], users[1], users[2]}, nil))
+
+	queries := db.recorded()
+	require.Len(t, queries, 1)
+	assert.Equal(t, "SELECT user_id, role_id, granted_at FROM role_users WHERE user_id IN ($1, $2, $3)", queries[0].query)
+
+	// Cada padre recibe su propia copia del hijo con los datos de su fila pivote
+	require.Len(t, users[0].Roles, 2)
+	assert.Equal(t, int64(10), users[0].Roles[0].Id)
+	assert.Equal(t, map[string]any{"granted_at": "2024-01-01"}, users[0].Roles[0].Pivot)
+	assert.Equal(t, map[string]any{"granted_at": "2024-03-01"}, users[0].Roles[1].Pivot)
+	require.Len(t, users[1].Roles, 1)
+	assert.Equal(t, map[string]any{"granted_at": "2024-02-01"}, users[1].Roles[0].Pivot)
+	assert.Nil(t, users[2].Roles)
+	assert.Nil(t, roles.rows[0].Pivot)
+}
+
+func TestManyToManyLoaderRequiresPivotField(t *testing.T) {
+	for name, loader := range map[string]*ManyToManyLoader[*loaderTestUser, *loaderTestRole]{
+		"columnas":   {PivoteColumns: []string{"granted_at"}},
+		"timestamps": {PivoteTimestamps: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conn, db := newFakeDB(nil)
+			loader.Repository = &fakeRepository[*loaderTestRole]{table: "roles"}
+			loader.Connection = conn
+			loader.ParentKey, loader.ChildKey, loader.ContainerField = "Id", "Id", "Roles"
+			loader.PivoteTable, loader.PivoteParentKey, loader.PivoteChildKey = "role_users", "user_id", "role_id"
+
+			err := loader.Load(context.Background(), []any{&loaderTestUser{Id: 1}}, nil)
+			assert.ErrorContains(t, err, "require PivoteField")
+			assert.Empty(t, db.recorded())
+		})
+	}
+}
+
+func TestGetPerParentQuery(t *testing.T) {
+	deletedAt := "deleted_at"
+	in := ComparatorIn
+	byPost := models.GroupFilter{Filters: []any{models.FilterMultipleValue{Key: "post_id", Values: []any{1, 2}, Comparator: &in}}}
+
+	tests := []struct {
+		name          string
+		softDelete    *string
+		opts          *models.Options
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name: "placeholders reutilizados en la subconsulta lateral",
+			opts: &models.Options{OrderColumn: "created_at", OrderDir: "DESC"},
+			expectedQuery: "SELECT godbsql_limited.* FROM (SELECT DISTINCT post_id AS godbsql_partition FROM comments WHERE post_id IN ($1, $2))" +
+				" AS godbsql_parents CROSS JOIN LATERAL (SELECT * FROM comments WHERE post_id = godbsql_parents.godbsql_partition" +
+				" AND (post_id IN ($1, $2)) ORDER BY created_at DESC LIMIT 3) AS godbsql_limited",
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:       "columnas, borrado lógico y límite global",
+			softDelete: &deletedAt,
+			opts:       &models.Options{Columns: &[]string{"id", "post_id"}, OrderColumn: "created_at", Limit: 10},
+			expectedQuery: "SELECT godbsql_limited.* FROM (SELECT DISTINCT post_id AS godbsql_partition FROM comments" +
+				" WHERE post_id IN ($1, $2) AND (deleted_at IS NULL)) AS godbsql_parents CROSS JOIN LATERAL (SELECT id, post_id FROM comments" +
+				" WHERE post_id = godbsql_parents.godbsql_partition AND (post_id IN ($1, $2) AND (deleted_at IS NULL))" +
+				" ORDER BY created_at ASC LIMIT 3) AS godbsql_limited LIMIT 10",
+			expectedArgs: []any{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, db := newFakeDB(nil)
+			comments := &Connection[*loaderTestComment]{
+				Conn: conn, Table: "comments", SoftDelete: tt.softDelete, OrderColumns: map[string]string{"created_at": "created_at"},
+			}
+
+			_, err := comments.GetPerParent(context.Background(), byPost, "post_id", 3, tt.opts)
+			require.NoError(t, err)
+
+			queries := db.recorded()
+			require.Len(t, queries, 1)
+			assert.Equal(t, tt.expectedQuery, queries[0].query)
+			assert.Equal(t, tt.expectedArgs, queries[0].args)
+		})
+	}
+}
+
+func TestGetPerParentErrors(t *testing.T) {
+	conn, db := newFakeDB(nil)
+	comments := &Connection[*loaderTestComment]{Conn: conn, Table: "comments", OrderColumns: map[string]string{"created_at": "created_at"}}
+	filters := models.GroupFilter{Filters: []any{}}
+
+	_, err := comments.GetPerParent(context.Background(), filters, "post_id", 3, nil)
+	assert.ErrorContains(t, err, "requires opts.OrderColumn")
+
+	_, err = comments.GetPerParent(context.Background(), filters, "post_id", 3, &models.Options{})
+	assert.ErrorContains(t, err, "requires opts.OrderColumn")
+
+	_, err = comments.GetPerParent(context.Background(), filters, "post_id) OR (1 = 1", 3, &models.Options{OrderColumn: "created_at"})
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+
+	_, err = comments.GetPerParent(context.Background(), filters, "post_id", 3, &models.Options{OrderColumn: "title"})
+	assert.ErrorContains(t, err, "invalid order column")
+
+	assert.Empty(t, db.recorded())
+}
+
+func TestRankPivotQuery(t *testing.T) {
+	deletedAt := "deleted_at"
+	active := models.GroupFilter{Filters: []any{models.Filter{Key: "active", Value: true}}}
+	pivotQuery := "SELECT user_id, role_id, granted_at FROM role_users WHERE user_id IN ($1, $2)"
+
+	tests := []struct {
+		name          string
+		softDelete    *string
+		constraint    RelationConstraint
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name:       "orden y filtros de la restricción",
+			constraint: RelationConstraint{OrderColumn: "name", OrderDir: "desc", Filters: &active, PerParentLimit: 2},
+			expectedQuery: "SELECT user_id, role_id, granted_at FROM (SELECT godbsql_pivot.*, ROW_NUMBER() OVER" +
+				" (PARTITION BY godbsql_pivot.user_id ORDER BY godbsql_child.name DESC) AS godbsql_row_number FROM (" + pivotQuery + ")" +
+				" AS godbsql_pivot JOIN (SELECT * FROM roles WHERE active = $3) AS godbsql_child ON godbsql_child.Id = godbsql_pivot.role_id)" +
+				" AS godbsql_ranked WHERE godbsql_row_number <= 2",
+			expectedArgs: []any{1, 2, true},
+		},
+		{
+			name:       "sin orden usa ChildKey y agrega el borrado lógico",
+			softDelete: &deletedAt,
+			constraint: RelationConstraint{PerParentLimit: 2},
+			expectedQuery: "SELECT user_id, role_id, granted_at FROM (SELECT godbsql_pivot.*, ROW_NUMBER() OVER" +
+				" (PARTITION BY godbsql_pivot.user_id ORDER BY godbsql_child.Id ASC) AS godbsql_row_number FROM (" + pivotQuery + ")" +
+				" AS godbsql_pivot JOIN (SELECT * FROM roles WHERE deleted_at IS NULL) AS godbsql_child ON godbsql_child.Id = godbsql_pivot.role_id)" +
+				" AS godbsql_ranked WHERE godbsql_row_number <= 2",
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:       "filtros y borrado lógico después de los argumentos pivote",
+			softDelete: &deletedAt,
+			constraint: RelationConstraint{OrderColumn: "name", Filters: &active, PerParentLimit: 1},
+			expectedQuery: "SELECT user_id, role_id, granted_at FROM (SELECT godbsql_pivot.*, ROW_NUMBER() OVER" +
+				" (PARTITION BY godbsql_pivot.user_id ORDER BY godbsql_child.name ASC) AS godbsql_row_number FROM (" + pivotQuery + ")" +
+				" AS godbsql_pivot JOIN (SELECT * FROM roles WHERE active = $3 AND (deleted_at IS NULL)) AS godbsql_child" +
+				" ON godbsql_child.Id = godbsql_pivot.role_id) AS godbsql_ranked WHERE godbsql_row_number <= 1",
+			expectedArgs: []any{1, 2, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &ManyToManyLoader[*loaderTestUser, *loaderTestRole]{
+				Repository: &Connection[*loaderTestRole]{Table: "roles", SoftDelete: tt.softDelete, OrderColumns: map[string]string{"name": "name"}},
+				ParentKey:  "Id", ChildKey: "Id", PivoteTable: "role_users", PivoteParentKey: "user_id", PivoteChildKey: "role_id",
+			}
+
+			query, args, err := loader.rankPivotQuery(pivotQuery, []any{1, 2}, []string{"granted_at"}, tt.constraint.PerParentLimit, &tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+
+	loader := &ManyToManyLoader[*loaderTestUser, *loaderTestRole]{
+		Repository: &Connection[*loaderTestRole]{Table: "roles"}, ChildKey: "Id", PivoteParentKey: "user_id", PivoteChildKey: "role_id",
+	}
+	_, _, err := loader.rankPivotQuery(pivotQuery, []any{1, 2}, nil, 2, &RelationConstraint{OrderColumn: "name"})
+	assert.ErrorContains(t, err, "invalid order column")
+}
+
+func TestOnetoManyLoaderPerParentLimit(t *testing.T) {
+	comments := &fakeRepository[*loaderTestComment]{
+		table: "comments",
+		rows:  []*loaderTestComment{{Id: 1, PostId: 100}, {Id: 2, PostId: 100}, {Id: 3, PostId: 100}, {Id: 4, PostId: 200}},
+	}
+	newLoader := func() *OnetoManyLoader[*loaderTestPost, *loaderTestComment] {
+		return &OnetoManyLoader[*loaderTestPost, *loaderTestComment]{
+			Repository: comments, ParentField: "Id", ChildFkField: "post_id", ContainerField: "Comments",
+		}
+	}
+
+	// Sin GetPerParent en el repositorio se recorta al asignar
+	posts := []*loaderTestPost{{Id: 100}, {Id: 200}}
+	limited := &RelationLoadOptions{Constraint: &RelationConstraint{OrderColumn: "id", PerParentLimit: 2}}
+	require.NoError(t, newLoader().LoadWithOptions(context.Background(), []any{posts[0], posts[1]}, nil, limited))
+	assert.Len(t, posts[0].Comments, 2)
+	assert.Len(t, posts[1].Comments, 1)
+
+	unordered := &RelationLoadOptions{Constraint: &RelationConstraint{PerParentLimit: 2}}
+	err := newLoader().LoadWithOptions(context.Background(), []any{&loaderTestPost{Id: 100}}, nil, unordered)
+	assert.ErrorContains(t, err, "requires OrderColumn")
+}
+
+func TestLoadersRejectUnsupportedPerParentLimit(t *testing.T) {
+	conn, db := newFakeDB(nil)
+	limited := &RelationLoadOptions{Constraint: &RelationConstraint{OrderColumn: "id", PerParentLimit: 1}}
+	profiles := &fakeRepository[*loaderTestProfile]{table: "profiles"}
+	teams := &fakeRepository[*loaderTestTeam]{table: "teams"}
+	notes := &fakeRepository[*loaderTestNote]{table: "notes"}
+	comments := &fakeRepository[*loaderTestComment]{table: "comments"}
+	teamId := int64(5)
+	user := &loaderTestUser{Id: 1, TeamId: &teamId}
+
+	tests := []struct {
+		name   string
+		loader RelationLoaderWithOptions
+		parent any
+	}{
+		{
+			name:   "1:1",
+			loader: &OnetoOneLoader[*loaderTestUser, *loaderTestProfile]{Repository: profiles, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Profile"},
+			parent: user,
+		},
+		{
+			name:   "pertenece a",
+			loader: &BelongsToLoader[*loaderTestUser, *loaderTestTeam]{Repository: teams, ParentFkField: "TeamId", ChildKey: "id", ContainerField: "Team"},
+			parent: user,
+		},
+		{
+			name: "a través de",
+			loader: &HasManyThroughLoader[*loaderTestUser, *loaderTestComment]{
+				Repository: comments, Connection: conn, ParentKey: "Id", ThroughTable: "posts",
+				ThroughParentKey: "user_id", ThroughKey: "id", ChildFkField: "post_id", ContainerField: "Comments",
+			},
+			parent: user,
+		},
+		{
+			name: "polimórfica 1:N",
+			loader: &MorphManyLoader[*loaderTestUser, *loaderTestNote]{
+				Repository: notes, ParentField: "Id", MorphIdField: "notable_id", MorphTypeField: "notable_type", MorphType: "user", ContainerField: "Notes",
+			},
+			parent: user,
+		},
+		{
+			name: "polimórfica inversa",
+			loader: &MorphToLoader[*loaderTestNote]{
+				MorphIdField: "NotableId", MorphTypeField: "NotableType", ContainerField: "Notable",
+				Owners: map[string]MorphOwner{"team": &MorphOwnerRepository[*loaderTestTeam]{Repository: teams, OwnerKey: "id"}},
+			},
+			parent: &loaderTestNote{Id: 1, NotableId: 5, NotableType: "team"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.loader.LoadWithOptions(context.Background(), []any{tt.parent}, nil, limited)
+			assert.ErrorContains(t, err, "PerParentLimit is only supported")
+		})
+	}
+
+	assert.Empty(t, db.recorded())
+	assert.Empty(t, profiles.gets)
+	assert.Empty(t, teams.gets)
+	assert.Empty(t, notes.gets)
+	assert.Empty(t, comments.gets)
+}
+
+func TestIndirectValue(t *testing.T) {
+	id := uuid.MustParse("0190a6d8-6f2b-7c3e-9a41-2f5d8e7b1c00")
+	number := int64(3)
+	var nilPointer *int64
+
+	tests := []struct {
+		name     string
+		value    any
+		expected any
+		ok       bool
+	}{
+		{name: "valor", value: int64(3), expected: int64(3), ok: true},
+		{name: "puntero", value: &number, expected: int64(3), ok: true},
+		{name: "puntero nulo", value: nilPointer, ok: false},
+		{name: "sql.NullInt64 válido", value: sql.NullInt64{Int64: 2, Valid: true}, expected: int64(2), ok: true},
+		{name: "sql.NullInt64 nulo", value: sql.NullInt64{Int64: 2}, ok: false},
+		{name: "sql.NullString válido", value: sql.NullString{String: "team", Valid: true}, expected: "team", ok: true},
+		{name: "uuid.NullUUID válido", value: uuid.NullUUID{UUID: id, Valid: true}, expected: id.String(), ok: true},
+		{name: "uuid.NullUUID nulo", value: uuid.NullUUID{}, ok: false},
+		{name: "uuid.UUID se conserva", value: id, expected: id, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := struct{ Field any }{Field: tt.value}
+			value, ok := indirectValue(reflect.ValueOf(holder).Field(0))
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, value)
+			}
+		})
+	}
+}
+
+type loaderTestSession struct {
+	Id     int64
+	UserId sql.NullInt64
+	User   *loaderTestUser
+}
+
+func (m *loaderTestSession) ScanFields() []any {
+	return []any{&m.Id, &m.UserId}
+}
+
+func TestBelongsToLoaderWithNullableForeignKey(t *testing.T) {
+	users := &fakeRepository[*loaderTestUser]{table: "users", rows: []*loaderTestUser{{Id: 2}}}
+	loader := &BelongsToLoader[*loaderTestSession, *loaderTestUser]{
+		Repository: users, ParentFkField: "UserId", ChildKey: "id", ContainerField: "User",
+	}
+
+	sessions := []*loaderTestSession{
+		{Id: 1, UserId: sql.NullInt64{Int64: 2, Valid: true}},
+		{Id: 2, UserId: sql.NullInt64{Int64: 9}},
+	}
+	require.NoError(t, loader.Load(context.Background(), []any{sessions[0], sessions[1]}, nil))
+
+	// La llave nula no se consulta y la válida viaja como int64
+	require.Len(t, users.gets, 1)
+	assert.Equal(t, []any{int64(2)}, users.gets[0].Filters[0].(models.FilterMultipleValue).Values)
+
+	require.NotNil(t, sessions[0].User)
+	assert.Equal(t, int64(2), sessions[0].User.Id)
+	assert.Nil(t, sessions[1].User)
+}
+
+func TestRequiredRelations(t *testing.T) {
+	profiles := &fakeRepository[*loaderTestProfile]{table: "profiles", rows: []*loaderTestProfile{{Id: 10, UserId: 1}}}
+	comments := &fakeRepository[*loaderTestComment]{table: "comments", rows: []*loaderTestComment{{Id: 1, PostId: 100}}}
+	teams := &fakeRepository[*loaderTestTeam]{table: "teams", rows: []*loaderTestTeam{{Id: 5}}}
+	teamId := int64(5)
+
+	tests := []struct {
+		name              string
+		loader            func(required bool) repository.RelationLoader
+		parents           func() []any
+		expectedField     string
+		expectedParentKey any
+		empty             func(parents []any) bool
+	}{
+		{
+			name: "1:1",
+			loader: func(required bool) repository.RelationLoader {
+				return &OnetoOneLoader[*loaderTestUser, *loaderTestProfile]{
+					Repository: profiles, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Profile", Required: required,
+				}
+			},
+			parents:           func() []any { return []any{&loaderTestUser{Id: 1}, &loaderTestUser{Id: 2}} },
+			expectedField:     "Profile",
+			expectedParentKey: int64(2),
+			empty:             func(parents []any) bool { return parents[1].(*loaderTestUser).Profile == nil },
+		},
+		{
+			name: "1:N",
+			loader: func(required bool) repository.RelationLoader {
+				return &OnetoManyLoader[*loaderTestPost, *loaderTestComment]{
+					Repository: comments, ParentField: "Id", ChildFkField: "post_id", ContainerField: "Comments", Required: required,
+				}
+			},
+			parents:           func() []any { return []any{&loaderTestPost{Id: 100}, &loaderTestPost{Id: 200}} },
+			expectedField:     "Comments",
+			expectedParentKey: int64(200),
+			empty:             func(parents []any) bool { return len(parents[1].(*loaderTestPost).Comments) == 0 },
+		},
+		{
+			name: "pertenece a con llave nula",
+			loader: func(required bool) repository.RelationLoader {
+				return &BelongsToLoader[*loaderTestUser, *loaderTestTeam]{
+					Repository: teams, ParentFkField: "TeamId", ChildKey: "id", ContainerField: "Team", Required: required,
+				}
+			},
+			parents:           func() []any { return []any{&loaderTestUser{Id: 1, TeamId: &teamId}, &loaderTestUser{Id: 2}} },
+			expectedField:     "Team",
+			expectedParentKey: nil,
+			empty:             func(parents []any) bool { return parents[1].(*loaderTestUser).Team == nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" requerida", func(t *testing.T) {
+			err := tt.loader(true).Load(context.Background(), tt.parents(), nil)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrRequiredRelation)
+
+			var missing *MissingRelationError
+			require.ErrorAs(t, err, &missing)
+			assert.Equal(t, tt.expectedField, missing.Field)
+			assert.Equal(t, tt.expectedParentKey, missing.ParentKey)
+		})
+
+		t.Run(tt.name+" opcional", func(t *testing.T) {
+			parents := tt.parents()
+			require.NoError(t, tt.loader(false).Load(context.Background(), parents, nil))
+			assert.True(t, tt.empty(parents))
+		})
+	}
+}
+
+func TestPrepareFilters(t *testing.T) {
+	gte := ComparatorGreaterThanOrEqual
+	notIn := ComparatorNotIn
+	overlaps := ComparatorArrayOverlaps
+
+	tests := []struct {
+		name          string
+		filters       models.GroupFilter
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "vacío",
+			filters:       models.GroupFilter{Filters: []any{}},
+			counter:       0,
+			expectedQuery: "",
+			expectedArgs:  []any{},
+			expectedNext:  1,
+		},
+		{
+			name: "filtros simples y múltiples",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				models.FilterMultipleValue{Key: "role", Values: []any{"admin", "editor", "admin"}},
+				models.FilterMultipleValue{Key: "id", Values: []any{7}, Comparator: &notIn},
+			}},
+			counter:       1,
+			expectedQuery: "status = $1 AND role IN ($2, $3) AND id NOT IN ($4)",
+			expectedArgs:  []any{"active", "admin", "editor", 7},
+			expectedNext:  5,
+		},
+		{
+			name: "grupo anidado con OR",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "age", Value: 18, Comparator: &gte},
+				models.GroupFilter{Operator: OperatorOr, Filters: []any{
+					models.Filter{Key: "country", Value: "MX"},
+					models.Filter{Key: "country", Value: "AR"},
+				}},
+				models.GroupFilter{Filters: []any{}},
+			}},
+			counter:       1,
+			expectedQuery: "age >= $1 AND (country = $2 OR country = $3)",
+			expectedArgs:  []any{18, "MX", "AR"},
+			expectedNext:  4,
+		},
+		{
+			name: "numeración continua dentro de EXISTS",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				WhereHas{Relation: "posts", Filters: &models.GroupFilter{Filters: []any{
+					models.Filter{Key: "title", Value: "go"},
+				}}},
+				WhereDoesntHave{Relation: "profile"},
+				models.Filter{Key: "name", Value: "ana"},
+			}},
+			counter: 1,
+			expectedQuery: "status = $1" +
+				" AND EXISTS (SELECT 1 FROM posts AS godbsql_has1 WHERE godbsql_has1.user_id = users.id AND (title = $2))" +
+				" AND NOT EXISTS (SELECT 1 FROM profiles AS godbsql_has1 WHERE godbsql_has1.user_id = users.id)" +
+				" AND name = $3",
+			expectedArgs: []any{"active", "go", "ana"},
+			expectedNext: 4,
+		},
+		{
+			name: "numeración continua entre subconsultas y EXISTS",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				SubqueryFilter{
+					Key: "id", Table: "orders", Column: "user_id",
+					Filters: &models.GroupFilter{Filters: []any{
+						models.Filter{Key: "total", Value: 100, Comparator: &gte},
+						SubqueryFilter{
+							Key: "product_id", Table: "products", Column: "id",
+							Filters: &models.GroupFilter{Filters: []any{models.Filter{Key: "stock", Value: 0}}},
+						},
+					}},
+				},
+				WhereHas{Relation: "posts", Filters: &models.GroupFilter{Filters: []any{
+					models.Filter{Key: "title", Value: "go"},
+				}}},
+				models.Filter{Key: "name", Value: "ana"},
+			}},
+			counter: 1,
+			expectedQuery: "status = $1" +
+				" AND id IN (SELECT user_id FROM orders WHERE total >= $2 AND product_id IN (SELECT id FROM products WHERE stock = $3))" +
+				" AND EXISTS (SELECT 1 FROM posts AS godbsql_has1 WHERE godbsql_has1.user_id = users.id AND (title = $4))" +
+				" AND name = $5",
+			expectedArgs: []any{"active", 100, 0, "go", "ana"},
+			expectedNext: 6,
+		},
+		{
+			name: "filtro JSON junto a un filtro simple",
+			filters: models.GroupFilter{Filters: []any{
+				JSONFilter{Key: "settings", Path: []string{"theme"}, Value: "dark"},
+				models.Filter{Key: "status", Value: "active"},
+			}},
+			counter:       1,
+			expectedQuery: "(settings->>$1::text) = $2 AND status = $3",
+			expectedArgs:  []any{"theme", "dark", "active"},
+			expectedNext:  4,
+		},
+		{
+			name: "arreglo junto a un filtro simple",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "tags", Value: []string{"go"}, Comparator: &overlaps},
+				models.Filter{Key: "status", Value: "active"},
+			}},
+			counter:       2,
+			expectedQuery: "tags && $2 AND status = $3",
+			expectedArgs:  []any{pq.Array([]string{"go"}), "active"},
+			expectedNext:  4,
+		},
+		{
+			name: "texto completo junto a un filtro simple",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				FullTextFilter{Key: "body", Query: "postgres"},
+			}},
+			counter:       1,
+			expectedQuery: "status = $1 AND to_tsvector(body) @@ websearch_to_tsquery($2)",
+			expectedArgs:  []any{"active", "postgres"},
+			expectedNext:  3,
+		},
+		{
+			name: "operador en minúsculas",
+			filters: models.GroupFilter{Operator: " or ", Filters: []any{
+				models.Filter{Key: "country", Value: "MX"},
+				models.Filter{Key: "country", Value: "AR"},
+			}},
+			counter:       3,
+			expectedQuery: "country = $3 OR country = $4",
+			expectedArgs:  []any{"MX", "AR"},
+			expectedNext:  5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareFilters(tt.filters, tt.counter, whereHasTestScope())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareFiltersErrors(t *testing.T) {
+	like := ComparatorLike
+	unknown := "<=>"
+
+	tests := []struct {
+		name    string
+		filters models.GroupFilter
+	}{
+		{
+			name:    "llave inválida",
+			filters: models.GroupFilter{Filters: []any{models.Filter{Key: "id = 1 OR 1", Value: 1}}},
+		},
+		{
+			name:    "comparador no soportado para múltiples valores",
+			filters: models.GroupFilter{Filters: []any{models.FilterMultipleValue{Key: "id", Values: []any{1}, Comparator: &like}}},
+		},
+		{
+			name: "error dentro de un grupo anidado",
+			filters: models.GroupFilter{Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				models.GroupFilter{Filters: []any{models.Filter{Key: "id", Value: 1, Comparator: &unknown}}},
+			}},
+		},
+		{
+			name:    "relación desconocida",
+			filters: models.GroupFilter{Filters: []any{WhereHas{Relation: "unknown"}}},
+		},
+		{
+			name: "operador de grupo inyectado",
+			filters: models.GroupFilter{Operator: "OR 1 = 1 OR", Filters: []any{
+				models.Filter{Key: "status", Value: "active"},
+				models.Filter{Key: "id", Value: 1},
+			}},
+		},
+		{
+			name: "operador de grupo anidado no soportado",
+			filters: models.GroupFilter{Filters: []any{
+				models.GroupFilter{Operator: "XOR", Filters: []any{models.Filter{Key: "id", Value: 1}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := prepareFilters(tt.filters, 1, whereHasTestScope())
+			assert.Error(t, err)
+		})
+	}
+}
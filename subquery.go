@@ -0,0 +1,76 @@
+package godbsql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+)
+
+// Filtro cuyo lado derecho es otra consulta:
+//   - IN / NOT IN (por defecto IN): Key IN (SELECT Column FROM Table WHERE Filters)
+//   - EXISTS / NOT EXISTS: EXISTS (SELECT 1 FROM Table WHERE Filters), Key y Column no se usan
+type SubqueryFilter struct {
+	Key        string
+	Comparator *string
+	Table      string
+	Column     string
+	Filters    *models.GroupFilter
+}
+
+// Los placeholders de la subconsulta continúan desde counter
+func prepareSubqueryFilter(filter SubqueryFilter, scope *filterScope, counter int) (string, []any, int, error) {
+	comparator := ComparatorIn
+	if filter.Comparator != nil {
+		comparator = normalizeComparator(*filter.Comparator)
+	}
+
+	if err := validateTableName(filter.Table); err != nil {
+		return "", nil, counter, err
+	}
+
+	depth := 0
+	if scope != nil {
+		depth = scope.depth
+	}
+
+	where := ""
+	var vals []any
+	if filter.Filters != nil {
+		var err error
+		where, vals, counter, err = prepareFilters(*filter.Filters, counter, &filterScope{table: filter.Table, depth: depth + 1})
+		if err != nil {
+			return "", nil, counter, err
+		}
+	}
+
+	var queryBuilder strings.Builder
+	switch comparator {
+	case ComparatorIn, ComparatorNotIn:
+		if filter.Key == "" || filter.Column == "" {
+			return "", nil, counter, fmt.Errorf("subquery filter %s requires key and column", comparator)
+		}
+
+		if err := validateIdentifier(filter.Key); err != nil {
+			return "", nil, counter, err
+		}
+
+		if err := validateIdentifier(filter.Column); err != nil {
+			return "", nil, counter, err
+		}
+
+		queryBuilder.WriteString(fmt.Sprintf("%s %s (SELECT %s FROM %s", filter.Key, comparator, filter.Column, filter.Table))
+	case ComparatorExists, ComparatorNotExists:
+		queryBuilder.WriteString(fmt.Sprintf("%s (SELECT 1 FROM %s", comparator, filter.Table))
+	default:
+		return "", nil, counter, fmt.Errorf("unsupported subquery comparator: %s", comparator)
+	}
+
+	if where != "" {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(where)
+	}
+	queryBuilder.WriteString(")")
+
+	return queryBuilder.String(), vals, counter, nil
+}
@@ -0,0 +1,121 @@
+package godbsql
+
+import (
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareSubqueryFilter(t *testing.T) {
+	notIn := ComparatorNotIn
+	exists := ComparatorExists
+	notExists := "not exists"
+	notInSpaced := " not  in "
+	gt := ComparatorGreaterThan
+
+	tests := []struct {
+		name          string
+		filter        SubqueryFilter
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name: "IN con filtros",
+			filter: SubqueryFilter{
+				Key: "id", Table: "orders", Column: "user_id",
+				Filters: &models.GroupFilter{Filters: []any{
+					models.Filter{Key: "total", Value: 100, Comparator: &gt},
+					models.Filter{Key: "status", Value: "paid"},
+				}},
+			},
+			counter:       2,
+			expectedQuery: "id IN (SELECT user_id FROM orders WHERE total > $2 AND status = $3)",
+			expectedArgs:  []any{100, "paid"},
+			expectedNext:  4,
+		},
+		{
+			name:          "NOT IN sin filtros",
+			filter:        SubqueryFilter{Key: "id", Comparator: &notIn, Table: "public.banned_users", Column: "user_id"},
+			counter:       1,
+			expectedQuery: "id NOT IN (SELECT user_id FROM public.banned_users)",
+			expectedNext:  1,
+		},
+		{
+			name:          "comparador con espacios y minúsculas",
+			filter:        SubqueryFilter{Key: "id", Comparator: &notInSpaced, Table: "banned_users", Column: "user_id"},
+			counter:       1,
+			expectedQuery: "id NOT IN (SELECT user_id FROM banned_users)",
+			expectedNext:  1,
+		},
+		{
+			name: "EXISTS correlacionado",
+			filter: SubqueryFilter{
+				Comparator: &exists, Table: "orders",
+				Filters: &models.GroupFilter{Filters: []any{
+					models.Filter{Key: "orders.user_id", Value: Column("users.id")},
+					models.Filter{Key: "orders.status", Value: "open"},
+				}},
+			},
+			counter:       5,
+			expectedQuery: "EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.status = $5)",
+			expectedArgs:  []any{"open"},
+			expectedNext:  6,
+		},
+		{
+			name: "NOT EXISTS con subconsulta anidada",
+			filter: SubqueryFilter{
+				Comparator: &notExists, Table: "orders",
+				Filters: &models.GroupFilter{Filters: []any{
+					models.Filter{Key: "status", Value: "open"},
+					SubqueryFilter{
+						Key: "product_id", Table: "products", Column: "id",
+						Filters: &models.GroupFilter{Filters: []any{models.Filter{Key: "stock", Value: 0}}},
+					},
+				}},
+			},
+			counter:       1,
+			expectedQuery: "NOT EXISTS (SELECT 1 FROM orders WHERE status = $1 AND product_id IN (SELECT id FROM products WHERE stock = $2))",
+			expectedArgs:  []any{"open", 0},
+			expectedNext:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := prepareSubqueryFilter(tt.filter, nil, tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			if len(tt.expectedArgs) == 0 {
+				assert.Empty(t, args)
+			} else {
+				assert.Equal(t, tt.expectedArgs, args)
+			}
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestPrepareSubqueryFilterErrors(t *testing.T) {
+	like := ComparatorLike
+
+	tests := []struct {
+		name   string
+		filter SubqueryFilter
+	}{
+		{name: "tabla inválida", filter: SubqueryFilter{Key: "id", Table: "orders o", Column: "user_id"}},
+		{name: "columna inválida", filter: SubqueryFilter{Key: "id", Table: "orders", Column: "user_id FROM users --"}},
+		{name: "IN sin columna", filter: SubqueryFilter{Key: "id", Table: "orders"}},
+		{name: "comparador no soportado", filter: SubqueryFilter{Key: "id", Table: "orders", Column: "user_id", Comparator: &like}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := prepareSubqueryFilter(tt.filter, nil, 1)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package godbsql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+)
+
+// Filtro para GroupFilter: padres que tienen al menos un hijo en la relación registrada Relation
+// (se admiten rutas con puntos, "roles.permissions") que cumpla Filters
+type WhereHas struct {
+	Relation string
+	Filters  *models.GroupFilter
+}
+
+// Filtro para GroupFilter: padres sin hijos en la relación Relation que cumplan Filters
+type WhereDoesntHave struct {
+	Relation string
+	Filters  *models.GroupFilter
+}
+
+// Tabla contra la que se compilan los filtros y sus relaciones registradas, necesarias para WhereHas.
+// table es el nombre o alias con que se califican las columnas del padre dentro de la subconsulta
+type filterScope struct {
+	table     string
+	relations map[string]repository.RelationLoader
+	depth     int
+}
+
+// Repositorio que expone sus relaciones para compilar WhereHas anidados
+type filterScoper interface {
+	filterScope(table string, depth int) *filterScope
+}
+
+// Loader capaz de compilar WhereHas con su configuración de llaves, devuelve la subconsulta del EXISTS
+type relationExistsQuerier interface {
+	existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error)
+}
+
+func (c *Connection[T]) filterScope(table string, depth int) *filterScope {
+	return &filterScope{table: table, relations: c.RelationLoaders, depth: depth}
+}
+
+func repositoryFilterScope(repo any, table string, depth int) *filterScope {
+	if scoper, ok := repo.(filterScoper); ok {
+		return scoper.filterScope(table, depth)
+	}
+
+	return &filterScope{table: table, depth: depth}
+}
+
+func compileWhereHas(relation string, nested *models.GroupFilter, negate bool, scope *filterScope, counter int) (string, []any, int, error) {
+	if scope == nil {
+		return "", nil, counter, fmt.Errorf("whereHas is not supported in this query: %s", relation)
+	}
+
+	name, rest, isNested := strings.Cut(relation, ".")
+	if name == "" {
+		return "", nil, counter, fmt.Errorf("invalid whereHas relation: %s", relation)
+	}
+
+	filters := models.GroupFilter{Filters: []any{}}
+	if isNested {
+		// "roles.permissions" equivale a WhereHas roles con un WhereHas permissions anidado
+		filters.Filters = append(filters.Filters, WhereHas{Relation: rest, Filters: nested})
+	} else if nested != nil {
+		filters = *nested
+	}
+
+	loader, ok := scope.relations[name]
+	if !ok {
+		return "", nil, counter, fmt.Errorf("relation loader not found for relation: %s", name)
+	}
+
+	querier, ok := loader.(relationExistsQuerier)
+	if !ok {
+		return "", nil, counter, fmt.Errorf("relation %s does not support whereHas", name)
+	}
+
+	query, vals, counter, err := querier.existsQuery(scope, filters, counter)
+	if err != nil {
+		return "", nil, counter, fmt.Errorf("failed to compile whereHas %s: %w", relation, err)
+	}
+
+	operator := "EXISTS"
+	if negate {
+		operator = "NOT EXISTS"
+	}
+
+	return fmt.Sprintf("%s (%s)", operator, query), vals, counter, nil
+}
+
+// Alias de la tabla hija, distinto por nivel para que un WhereHas anidado pueda referirse a su padre
+func existsAlias(parent *filterScope) string {
+	return fmt.Sprintf("godbsql_has%d", parent.depth+1)
+}
+
+// Filtros anidados de un WhereHas compilados contra la tabla hija, con su borrado lógico
+func childExistsFilters(repo any, alias string, parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if softDelete, ok := repo.(softDeleteFilterer); ok {
+		nested = softDelete.softDeleteFilters(nested)
+	}
+
+	return prepareFilters(nested, counter, repositoryFilterScope(repo, alias, parent.depth+1))
+}
+
+// SELECT 1 FROM tabla AS alias WHERE condición AND (filtros anidados)
+func existsSubquery(repo any, table, alias, condition string, condVals []any, parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := validateTableName(table); err != nil {
+		return "", nil, counter, err
+	}
+
+	where, vals, counter, err := childExistsFilters(repo, alias, parent, nested, counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	query := fmt.Sprintf("SELECT 1 FROM %s AS %s WHERE %s", table, alias, condition)
+	if where != "" {
+		query += fmt.Sprintf(" AND (%s)", where)
+	}
+
+	return query, append(condVals, vals...), counter, nil
+}
+
+func (l *OnetoManyLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := l.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	condition := fmt.Sprintf("%s.%s = %s.%s", alias, l.ChildFkField, parent.table, fieldColumn(l.ParentField))
+
+	return existsSubquery(l.Repository, l.Repository.GetTableName(), alias, condition, nil, parent, nested, counter)
+}
+
+func (c *OnetoOneLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := c.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	condition := fmt.Sprintf("%s.%s = %s.%s", alias, c.ChildFkField, parent.table, fieldColumn(c.ParentField))
+
+	return existsSubquery(c.Repository, c.Repository.GetTableName(), alias, condition, nil, parent, nested, counter)
+}
+
+func (b *BelongsToLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := b.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	condition := fmt.Sprintf("%s.%s = %s.%s", alias, b.ChildKey, parent.table, fieldColumn(b.ParentFkField))
+
+	return existsSubquery(b.Repository, b.Repository.GetTableName(), alias, condition, nil, parent, nested, counter)
+}
+
+func (m *MorphManyLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := m.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	condition := fmt.Sprintf("%s.%s = %s.%s AND %s.%s = $%d", alias, m.MorphIdField, parent.table, fieldColumn(m.ParentField), alias, m.MorphTypeField, counter)
+
+	return existsSubquery(m.Repository, m.Repository.GetTableName(), alias, condition, []any{m.MorphType}, parent, nested, counter+1)
+}
+
+// Los hijos se buscan dentro de la tabla pivote sin JOIN, así las columnas de los filtros anidados no son ambiguas
+func (m *ManyToManyLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := m.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	childTable := m.Repository.GetTableName()
+	if err := validateTableName(childTable); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	pivotAlias := alias + "_pivot"
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(fmt.Sprintf("SELECT 1 FROM %s AS %s WHERE %s.%s = %s.%s", m.PivoteTable, pivotAlias, pivotAlias, m.PivoteParentKey, parent.table, fieldColumn(m.ParentKey)))
+
+	args := []any{}
+	if m.PivoteFilters != nil {
+		pivotFilters, pivotVals, newCounter, err := prepareFilters(*m.PivoteFilters, counter, nil)
+		if err != nil {
+			return "", nil, counter, err
+		}
+		counter = newCounter
+
+		if pivotFilters != "" {
+			queryBuilder.WriteString(fmt.Sprintf(" AND (%s)", pivotFilters))
+			args = append(args, pivotVals...)
+		}
+	}
+
+	childWhere, childVals, counter, err := childExistsFilters(m.Repository, alias, parent, nested, counter)
+	if err != nil {
+		return "", nil, counter, err
+	}
+
+	queryBuilder.WriteString(fmt.Sprintf(" AND %s.%s IN (SELECT %s.%s FROM %s AS %s", pivotAlias, m.PivoteChildKey, alias, m.ChildKey, childTable, alias))
+	if childWhere != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" WHERE %s", childWhere))
+		args = append(args, childVals...)
+	}
+	queryBuilder.WriteString(")")
+
+	return queryBuilder.String(), args, counter, nil
+}
+
+func (h *HasManyThroughLoader[P, C]) existsQuery(parent *filterScope, nested models.GroupFilter, counter int) (string, []any, int, error) {
+	if err := h.validateIdentifiers(); err != nil {
+		return "", nil, counter, err
+	}
+
+	alias := existsAlias(parent)
+	throughAlias := alias + "_through"
+	condition := fmt.Sprintf("%s.%s IN (SELECT %s.%s FROM %s AS %s WHERE %s.%s = %s.%s)",
+		alias, h.ChildFkField,
+		throughAlias, h.ThroughKey, h.ThroughTable, throughAlias,
+		throughAlias, h.ThroughParentKey, parent.table, fieldColumn(h.ParentKey),
+	)
+
+	return existsSubquery(h.Repository, h.Repository.GetTableName(), alias, condition, nil, parent, nested, counter)
+}
+
+// Columna de un campo del modelo (UserId -> user_id); los nombres que ya son columnas se dejan igual
+func fieldColumn(field string) string {
+	if strings.Contains(field, "_") || strings.ToLower(field) == field {
+		return field
+	}
+
+	runes := []rune(field)
+	var buffer strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Nuevo segmento si viene de minúscula/dígito, o si termina un acrónimo (IDNumber -> id_number)
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				buffer.WriteRune('_')
+			}
+			buffer.WriteRune(unicode.ToLower(r))
+			continue
+		}
+
+		buffer.WriteRune(r)
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,177 @@
+package godbsql
+
+import (
+	"testing"
+
+	"github.com/Nemutagk/godb/v2/definitions/models"
+	"github.com/Nemutagk/godb/v2/definitions/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type whereHasTestModel struct {
+	Id     string
+	UserId string
+}
+
+func (m *whereHasTestModel) ScanFields() []any {
+	return []any{&m.Id, &m.UserId}
+}
+
+// users -> posts (1:N), profile (1:1), roles (N:M) -> permissions (1:N), comments (polimórfica),
+// team (pertenece a), comments por posts (a través de)
+func whereHasTestScope() *filterScope {
+	deletedAt := "deleted_at"
+	permissions := &Connection[*whereHasTestModel]{Table: "permissions", SoftDelete: &deletedAt}
+	roles := &Connection[*whereHasTestModel]{
+		Table: "roles",
+		RelationLoaders: map[string]repository.RelationLoader{
+			"permissions": &OnetoManyLoader[*whereHasTestModel, *whereHasTestModel]{Repository: permissions, ParentField: "Id", ChildFkField: "role_id", ContainerField: "Permissions"},
+		},
+	}
+	posts := &Connection[*whereHasTestModel]{Table: "posts"}
+	profiles := &Connection[*whereHasTestModel]{Table: "profiles"}
+	comments := &Connection[*whereHasTestModel]{Table: "comments"}
+	teams := &Connection[*whereHasTestModel]{Table: "teams"}
+
+	users := &Connection[*whereHasTestModel]{
+		Table: "users",
+		RelationLoaders: map[string]repository.RelationLoader{
+			"posts":   &OnetoManyLoader[*whereHasTestModel, *whereHasTestModel]{Repository: posts, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Posts"},
+			"profile": &OnetoOneLoader[*whereHasTestModel, *whereHasTestModel]{Repository: profiles, ParentField: "Id", ChildFkField: "user_id", ContainerField: "Profile"},
+			"team":    &BelongsToLoader[*whereHasTestModel, *whereHasTestModel]{Repository: teams, ParentFkField: "TeamId", ChildKey: "id", ContainerField: "Team"},
+			"roles": &ManyToManyLoader[*whereHasTestModel, *whereHasTestModel]{
+				Repository: roles, ParentKey: "Id", ChildKey: "id", PivoteTable: "role_users",
+				PivoteParentKey: "user_id", PivoteChildKey: "role_id", ContainerField: "Roles",
+			},
+			"comments": &MorphManyLoader[*whereHasTestModel, *whereHasTestModel]{
+				Repository: comments, ParentField: "Id", MorphIdField: "commentable_id", MorphTypeField: "commentable_type",
+				MorphType: "user", ContainerField: "Comments",
+			},
+			"postComments": &HasManyThroughLoader[*whereHasTestModel, *whereHasTestModel]{
+				Repository: comments, ParentKey: "Id", ThroughTable: "posts", ThroughParentKey: "user_id",
+				ThroughKey: "id", ChildFkField: "post_id", ContainerField: "PostComments",
+			},
+		},
+	}
+
+	return users.filterScope("users", 0)
+}
+
+func TestCompileWhereHas(t *testing.T) {
+	published := models.GroupFilter{Filters: []any{models.Filter{Key: "status", Value: "published"}}}
+	adminPermission := models.GroupFilter{Filters: []any{models.Filter{Key: "name", Value: "admin"}}}
+
+	tests := []struct {
+		name          string
+		relation      string
+		nested        *models.GroupFilter
+		negate        bool
+		counter       int
+		expectedQuery string
+		expectedArgs  []any
+		expectedNext  int
+	}{
+		{
+			name:          "1:N sin filtros",
+			relation:      "posts",
+			counter:       1,
+			expectedQuery: "EXISTS (SELECT 1 FROM posts AS godbsql_has1 WHERE godbsql_has1.user_id = users.id)",
+			expectedNext:  1,
+		},
+		{
+			name:          "1:N con filtros",
+			relation:      "posts",
+			nested:        &published,
+			counter:       3,
+			expectedQuery: "EXISTS (SELECT 1 FROM posts AS godbsql_has1 WHERE godbsql_has1.user_id = users.id AND (status = $3))",
+			expectedArgs:  []any{"published"},
+			expectedNext:  4,
+		},
+		{
+			name:          "sin hijos",
+			relation:      "profile",
+			negate:        true,
+			counter:       1,
+			expectedQuery: "NOT EXISTS (SELECT 1 FROM profiles AS godbsql_has1 WHERE godbsql_has1.user_id = users.id)",
+			expectedNext:  1,
+		},
+		{
+			name:          "pertenece a",
+			relation:      "team",
+			counter:       1,
+			expectedQuery: "EXISTS (SELECT 1 FROM teams AS godbsql_has1 WHERE godbsql_has1.id = users.team_id)",
+			expectedNext:  1,
+		},
+		{
+			name:          "polimórfica",
+			relation:      "comments",
+			nested:        &published,
+			counter:       2,
+			expectedQuery: "EXISTS (SELECT 1 FROM comments AS godbsql_has1 WHERE godbsql_has1.commentable_id = users.id AND godbsql_has1.commentable_type = $2 AND (status = $3))",
+			expectedArgs:  []any{"user", "published"},
+			expectedNext:  4,
+		},
+		{
+			name:          "a través de",
+			relation:      "postComments",
+			counter:       1,
+			expectedQuery: "EXISTS (SELECT 1 FROM comments AS godbsql_has1 WHERE godbsql_has1.post_id IN (SELECT godbsql_has1_through.id FROM posts AS godbsql_has1_through WHERE godbsql_has1_through.user_id = users.id))",
+			expectedNext:  1,
+		},
+		{
+			name:     "N:M anidada con borrado lógico",
+			relation: "roles.permissions",
+			nested:   &adminPermission,
+			counter:  2,
+			expectedQuery: "EXISTS (SELECT 1 FROM role_users AS godbsql_has1_pivot WHERE godbsql_has1_pivot.user_id = users.id" +
+				" AND godbsql_has1_pivot.role_id IN (SELECT godbsql_has1.id FROM roles AS godbsql_has1" +
+				" WHERE EXISTS (SELECT 1 FROM permissions AS godbsql_has2 WHERE godbsql_has2.role_id = godbsql_has1.id" +
+				" AND (name = $2 AND (deleted_at IS NULL)))))",
+			expectedArgs: []any{"admin"},
+			expectedNext: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, next, err := compileWhereHas(tt.relation, tt.nested, tt.negate, whereHasTestScope(), tt.counter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedQuery, query)
+			if len(tt.expectedArgs) == 0 {
+				assert.Empty(t, args)
+			} else {
+				assert.Equal(t, tt.expectedArgs, args)
+			}
+			assert.Equal(t, tt.expectedNext, next)
+		})
+	}
+}
+
+func TestCompileWhereHasErrors(t *testing.T) {
+	_, _, _, err := compileWhereHas("unknown", nil, false, whereHasTestScope(), 1)
+	assert.ErrorContains(t, err, "relation loader not found")
+
+	_, _, _, err = compileWhereHas("roles.unknown", nil, false, whereHasTestScope(), 1)
+	assert.ErrorContains(t, err, "relation loader not found")
+
+	_, _, _, err = compileWhereHas(".posts", nil, false, whereHasTestScope(), 1)
+	assert.Error(t, err)
+
+	_, _, _, err = compileWhereHas("posts", nil, false, nil, 1)
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestFieldColumn(t *testing.T) {
+	tests := map[string]string{
+		"Id":       "id",
+		"UserId":   "user_id",
+		"IDNumber": "id_number",
+		"user_id":  "user_id",
+		"team":     "team",
+	}
+
+	for field, expected := range tests {
+		assert.Equal(t, expected, fieldColumn(field), field)
+	}
+}